@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/xyproto/jpath"
+	"io"
+	"log"
+	"os"
+)
+
+func main() {
+	compact := flag.Bool("c", false, "compact output instead of indented output")
+	inPlace := flag.Bool("w", false, "write the result to the given file instead of to stdout")
+	indent := flag.String("indent", "  ", "the string to use for indentation")
+	flag.Parse()
+
+	if len(flag.Args()) > 1 || (*inPlace && len(flag.Args()) != 1) {
+		fmt.Println("Syntax: jfmt [-c] [-w] [-indent string] [filename]")
+		fmt.Println("Example: jfmt -w books.json")
+		os.Exit(1)
+	}
+
+	// Read from the given file, or from stdin
+	var r io.Reader = os.Stdin
+	if len(flag.Args()) == 1 {
+		f, err := os.Open(flag.Args()[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	// Decode numbers as json.Number, to preserve their precision
+	document, err := jpath.NewFromReaderNumber(r)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Map keys are always sorted when marshaling
+	data, err := document.JSON()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if !*compact {
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, data, "", *indent); err != nil {
+			log.Fatal(err)
+		}
+		data = buf.Bytes()
+	}
+	data = append(data, '\n')
+
+	if *inPlace {
+		if err := os.WriteFile(flag.Args()[0], data, 0666); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	os.Stdout.Write(data)
+}
@@ -0,0 +1,4 @@
+#!/bin/sh
+go run main.go messy.json | diff messy.expected - && echo "indented: OK"
+go run main.go -c messy.json | diff messy.compact.expected - && echo "compact: OK"
+go run main.go -indent '  ' < messy.json | diff messy.expected - && echo "stdin: OK"
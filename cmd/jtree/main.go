@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/xyproto/jpath"
+	"log"
+	"os"
+)
+
+func main() {
+	depth := flag.Int("depth", 0, "only show maps and lists down to the given depth, 0 for no limit")
+	types := flag.Bool("types", false, "annotate each value with its type")
+	flag.Parse()
+
+	if len(flag.Args()) > 1 || *depth < 0 {
+		fmt.Println("Syntax: jtree [-depth N] [-types] [filename]")
+		fmt.Println("Example: jtree -depth 2 books.json")
+		os.Exit(1)
+	}
+
+	// Read from the given file, or from stdin
+	var (
+		document *jpath.Node
+		err      error
+	)
+	if len(flag.Args()) == 1 {
+		document, err = jpath.NewFromFile(flag.Args()[0])
+	} else {
+		document, err = jpath.NewFromReader(os.Stdin)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := document.WriteTree(os.Stdout, *depth, *types); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,4 @@
+#!/bin/sh
+go run main.go books.json
+go run main.go -types books.json
+go run main.go -depth 1 < books.json
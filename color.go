@@ -0,0 +1,127 @@
+package jpath
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+)
+
+// ANSI escape codes for the different parts of colorized JSON output
+const (
+	colorReset  = "\033[0m"
+	colorKey    = "\033[34m" // blue
+	colorString = "\033[32m" // green
+	colorNumber = "\033[36m" // cyan
+	colorBool   = "\033[33m" // yellow
+	colorNull   = "\033[90m" // gray
+)
+
+// WriteColorJSON writes the node as indented JSON to the given io.Writer.
+// If color is true, keys, strings, numbers, booleans and nulls are colored
+// with ANSI escape codes, which is useful when writing to a terminal.
+func (j *Node) WriteColorJSON(w io.Writer, color bool) error {
+	bw := bufio.NewWriter(w)
+	if err := writeColorJSON(bw, j.data, color, 0); err != nil {
+		return err
+	}
+	if _, err := bw.WriteString("\n"); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// writeColored writes s to w, surrounded by the given ANSI color code if color is true
+func writeColored(w *bufio.Writer, s, code string, color bool) {
+	if color {
+		w.WriteString(code)
+	}
+	w.WriteString(s)
+	if color {
+		w.WriteString(colorReset)
+	}
+}
+
+// writeColorJSON writes the given value as indented and optionally colored JSON
+func writeColorJSON(w *bufio.Writer, v interface{}, color bool, depth int) error {
+	indent := strings.Repeat("  ", depth+1)
+	switch v := v.(type) {
+	case *Node:
+		return writeColorJSON(w, v.data, color, depth)
+	case nil:
+		writeColored(w, "null", colorNull, color)
+	case bool:
+		b, _ := json.Marshal(v)
+		writeColored(w, string(b), colorBool, color)
+	case string:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		writeColored(w, string(b), colorString, color)
+	case json.Number, float32, float64, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		writeColored(w, string(b), colorNumber, color)
+	case map[string]interface{}:
+		if len(v) == 0 {
+			w.WriteString("{}")
+			return nil
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		w.WriteString("{\n")
+		for i, key := range keys {
+			b, err := json.Marshal(key)
+			if err != nil {
+				return err
+			}
+			w.WriteString(indent)
+			writeColored(w, string(b), colorKey, color)
+			w.WriteString(": ")
+			if err := writeColorJSON(w, v[key], color, depth+1); err != nil {
+				return err
+			}
+			if i < len(keys)-1 {
+				w.WriteString(",")
+			}
+			w.WriteString("\n")
+		}
+		w.WriteString(indent[2:] + "}")
+	case []interface{}:
+		if len(v) == 0 {
+			w.WriteString("[]")
+			return nil
+		}
+		w.WriteString("[\n")
+		for i, val := range v {
+			w.WriteString(indent)
+			if err := writeColorJSON(w, val, color, depth+1); err != nil {
+				return err
+			}
+			if i < len(v)-1 {
+				w.WriteString(",")
+			}
+			w.WriteString("\n")
+		}
+		w.WriteString(indent[2:] + "]")
+	default:
+		// Convert other types to basic JSON types first
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		n, err := NewNumber(b)
+		if err != nil {
+			return err
+		}
+		return writeColorJSON(w, n.data, color, depth)
+	}
+	return nil
+}
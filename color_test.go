@@ -0,0 +1,33 @@
+package jpath
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/bmizerany/assert"
+)
+
+func TestWriteColorJSON(t *testing.T) {
+	js, err := New([]byte(`{"name": "Bob", "age": 42, "admin": false, "pet": null, "tags": ["a", "b"], "empty": {}}`))
+	assert.Equal(t, nil, err)
+
+	var buf bytes.Buffer
+	err = js.WriteColorJSON(&buf, false)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, strings.Contains(buf.String(), "\033["))
+
+	// Without colors, the output should be the same as for PrettyJSON
+	pretty, err := js.PrettyJSON()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, string(pretty)+"\n", buf.String())
+
+	buf.Reset()
+	err = js.WriteColorJSON(&buf, true)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, strings.Contains(buf.String(), colorKey+`"name"`+colorReset))
+	assert.Equal(t, true, strings.Contains(buf.String(), colorString+`"Bob"`+colorReset))
+	assert.Equal(t, true, strings.Contains(buf.String(), colorNumber+`42`+colorReset))
+	assert.Equal(t, true, strings.Contains(buf.String(), colorBool+`false`+colorReset))
+	assert.Equal(t, true, strings.Contains(buf.String(), colorNull+`null`+colorReset))
+}
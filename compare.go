@@ -0,0 +1,225 @@
+package jpath
+
+import (
+	"encoding/json"
+	"math"
+)
+
+// Equals checks if two nodes have the same contents. Numbers are compared by
+// value, so 1, 1.0 and json.Number("1e0") are all equal, regardless of their types.
+func (j *Node) Equals(other *Node) bool {
+	return valuesEqual(j.data, other.data)
+}
+
+// EqualsApprox is like Equals, but numbers are considered equal if they
+// differ by at most epsilon. Useful for comparing computed numbers.
+func (j *Node) EqualsApprox(other *Node, epsilon float64) bool {
+	return approxEqual(j.data, other.data, epsilon)
+}
+
+// isNumber checks if the given value is a number
+func isNumber(v interface{}) bool {
+	switch v.(type) {
+	case json.Number, float32, float64, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return true
+	}
+	return false
+}
+
+// numbersEqual compares two numbers by value. If epsilon is 0, integers are
+// compared exactly, while other numbers are compared as float64.
+// If not, the numbers are equal if they differ by at most epsilon.
+func numbersEqual(a, b interface{}, epsilon float64) bool {
+	na, nb := &Node{a}, &Node{b}
+	if epsilon == 0 {
+		if ia, ok := na.CheckBigInt(); ok {
+			if ib, ok := nb.CheckBigInt(); ok {
+				return ia.Cmp(ib) == 0
+			}
+		}
+	}
+	fa, okA := na.CheckFloat64()
+	fb, okB := nb.CheckFloat64()
+	return okA && okB && math.Abs(fa-fb) <= epsilon
+}
+
+// valuesEqual checks if two values are equal, comparing maps and lists recursively,
+// and numbers by value
+func valuesEqual(a, b interface{}) bool {
+	return approxEqual(a, b, 0)
+}
+
+// approxEqual is like valuesEqual, but numbers are compared with numbersEqual and the given epsilon
+func approxEqual(a, b interface{}, epsilon float64) bool {
+	if n, ok := a.(*Node); ok {
+		a = n.data
+	}
+	if n, ok := b.(*Node); ok {
+		b = n.data
+	}
+	if isNumber(a) || isNumber(b) {
+		return isNumber(a) && isNumber(b) && numbersEqual(a, b, epsilon)
+	}
+	switch a := a.(type) {
+	case nil:
+		return b == nil
+	case bool:
+		bb, ok := b.(bool)
+		return ok && a == bb
+	case string:
+		bs, ok := b.(string)
+		return ok && a == bs
+	case map[string]interface{}:
+		bm, ok := b.(map[string]interface{})
+		if !ok || len(a) != len(bm) {
+			return false
+		}
+		for key, val := range a {
+			bval, ok := bm[key]
+			if !ok || !approxEqual(val, bval, epsilon) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		bl, ok := b.([]interface{})
+		if !ok || len(a) != len(bl) {
+			return false
+		}
+		for i, val := range a {
+			if !approxEqual(val, bl[i], epsilon) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// CountValue returns the number of strings, numbers, booleans and nulls in the
+// document that are equal to the given value, compared in the same way as Equals
+func (j *Node) CountValue(v interface{}) int {
+	count := 0
+	walkLeaves(j.data, func(leaf interface{}) {
+		if valuesEqual(leaf, v) {
+			count++
+		}
+	})
+	return count
+}
+
+// walkLeaves calls fn for each value in the given value that is not a map or a list
+func walkLeaves(v interface{}, fn func(leaf interface{})) {
+	switch v := v.(type) {
+	case *Node:
+		walkLeaves(v.data, fn)
+	case map[string]interface{}:
+		for _, val := range v {
+			walkLeaves(val, fn)
+		}
+	case []interface{}:
+		for _, val := range v {
+			walkLeaves(val, fn)
+		}
+	default:
+		fn(v)
+	}
+}
+
+// ReplaceValue replaces all strings, numbers, booleans and nulls in the document
+// that are equal to old with new, compared in the same way as Equals.
+// Returns the number of values that were replaced. NilNode is never modified.
+func (j *Node) ReplaceValue(old, new interface{}) int {
+	if j == NilNode {
+		return 0
+	}
+	if n, ok := new.(*Node); ok {
+		new = n.data
+	}
+	count := 0
+	j.data = replaceLeaves(j.data, old, new, &count)
+	return count
+}
+
+// replaceLeaves replaces the values in v that are equal to old with new,
+// modifying maps and lists in place, and returns the resulting value
+func replaceLeaves(v, old, new interface{}, count *int) interface{} {
+	switch v := v.(type) {
+	case *Node:
+		v.data = replaceLeaves(v.data, old, new, count)
+		return v
+	case map[string]interface{}:
+		for key, val := range v {
+			v[key] = replaceLeaves(val, old, new, count)
+		}
+		return v
+	case []interface{}:
+		for i, val := range v {
+			v[i] = replaceLeaves(val, old, new, count)
+		}
+		return v
+	}
+	if valuesEqual(v, old) {
+		*count++
+		return new
+	}
+	return v
+}
+
+// FindPaths returns the JSON paths to all strings, numbers, booleans and nulls
+// in the document that are equal to v, like "x.people.names[0]".
+// Map keys are visited in sorted order. Returns an empty slice if v is not found.
+func (j *Node) FindPaths(v interface{}) []string {
+	paths := []string{}
+	walkLeafPaths("x", j.data, func(JSONpath string, leaf interface{}) {
+		if valuesEqual(leaf, v) {
+			paths = append(paths, JSONpath)
+		}
+	})
+	return paths
+}
+
+// DiffCount returns the number of leaf JSON paths that differ between two documents,
+// including paths that are only present in one of them. Empty maps and lists count as
+// leaves, so that {"a": {}} differs from both {"a": []} and {}. Values are compared in the
+// same way as Equals. Useful as a cheap measure of how much a document has changed.
+func (j *Node) DiffCount(other *Node) int {
+	leaves := make(map[string]interface{})
+	walkDiffLeaves("x", j.data, func(JSONpath string, leaf interface{}) {
+		leaves[JSONpath] = leaf
+	})
+	count := 0
+	walkDiffLeaves("x", other.data, func(JSONpath string, leaf interface{}) {
+		val, ok := leaves[JSONpath]
+		if !ok || !valuesEqual(val, leaf) {
+			count++
+		}
+		delete(leaves, JSONpath)
+	})
+	// Count the leaves that are only present in this document
+	return count + len(leaves)
+}
+
+// walkDiffLeaves is like walkLeafPaths, but fn is also called for empty maps and lists
+func walkDiffLeaves(JSONpath string, v interface{}, fn func(JSONpath string, leaf interface{})) {
+	switch v := v.(type) {
+	case *Node:
+		walkDiffLeaves(JSONpath, v.data, fn)
+	case map[string]interface{}:
+		if len(v) == 0 {
+			fn(JSONpath, v)
+		}
+		for key, val := range v {
+			walkDiffLeaves(keyPath(JSONpath, key), val, fn)
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			fn(JSONpath, v)
+		}
+		for i, val := range v {
+			walkDiffLeaves(indexPath(JSONpath, i), val, fn)
+		}
+	default:
+		fn(JSONpath, v)
+	}
+}
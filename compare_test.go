@@ -0,0 +1,107 @@
+package jpath
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bmizerany/assert"
+)
+
+func TestEquals(t *testing.T) {
+	a, err := New([]byte(`{"a": [1, 2.5, "x"], "b": {"c": null, "d": true}}`))
+	assert.Equal(t, nil, err)
+	b, err := NewNumber([]byte(`{"b": {"d": true, "c": null}, "a": [1.0, 2.5, "x"]}`))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, a.Equals(b))
+	assert.Equal(t, true, b.Equals(a))
+
+	assert.Equal(t, true, (&Node{1}).Equals(&Node{json.Number("1e0")}))
+	assert.Equal(t, false, (&Node{1}).Equals(&Node{"1"}))
+	assert.Equal(t, false, a.Equals(&Node{map[string]interface{}{"a": []interface{}{1}}}))
+}
+
+func TestCountValue(t *testing.T) {
+	js, err := New([]byte(`{"a": "N/A", "b": [null, "N/A", {"c": "N/A", "d": null}], "e": 1, "f": "n/a"}`))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, js.CountValue("N/A"))
+	assert.Equal(t, 2, js.CountValue(nil))
+	assert.Equal(t, 1, js.CountValue(1))
+	assert.Equal(t, 0, js.CountValue("missing"))
+}
+
+func TestReplaceValue(t *testing.T) {
+	js, err := New([]byte(`{"a": "N/A", "b": [1, "N/A", {"c": "N/A", "d": "ok"}], "e": 2}`))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, js.ReplaceValue("N/A", nil))
+	assert.Equal(t, nil, js.Get("a").Interface())
+	assert.Equal(t, nil, js.GetNode(".b[1]").Interface())
+	assert.Equal(t, nil, js.GetNode(".b[2].c").Interface())
+	assert.Equal(t, "ok", js.GetNode(".b[2].d").String())
+	assert.Equal(t, 0, js.CountValue("N/A"))
+
+	// Numbers are compared by value
+	assert.Equal(t, 1, js.ReplaceValue(2.0, 3))
+	assert.Equal(t, 3, js.Get("e").Int())
+	assert.Equal(t, 0, js.ReplaceValue("missing", "x"))
+
+	// Values that are nodes are replaced within
+	wrapped := &Node{map[string]interface{}{"n": &Node{"N/A"}}}
+	assert.Equal(t, 1, wrapped.ReplaceValue("N/A", "x"))
+	assert.Equal(t, "x", wrapped.Map()["n"].(*Node).String())
+
+	// NilNode is never modified
+	assert.Equal(t, 0, js.Get("missing").ReplaceValue(nil, "x"))
+	assert.Equal(t, nil, NilNode.Interface())
+}
+
+func TestFindPaths(t *testing.T) {
+	js, err := New([]byte(`{"jobs": [{"status": "ok"}, {"status": "error"}], "last": {"status": "error"}}`))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"x.jobs[1].status", "x.last.status"}, js.FindPaths("error"))
+	for _, path := range js.FindPaths("error") {
+		assert.Equal(t, "error", js.GetNode(path).String())
+	}
+	assert.Equal(t, []string{}, js.FindPaths("missing"))
+}
+
+func TestDiffCount(t *testing.T) {
+	a, err := New([]byte(`{"name": "app", "db": {"host": "localhost", "port": 5432}, "tags": ["a", "b"], "debug": false}`))
+	assert.Equal(t, nil, err)
+	b, err := New([]byte(`{"name": "app", "db": {"host": "localhost", "port": 5432}, "tags": ["a", "b"], "debug": false}`))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, a.DiffCount(b))
+
+	// One changed value, one added value and one removed value
+	c, err := New([]byte(`{"name": "app", "db": {"host": "db.example.com", "port": 5432}, "tags": ["a", "b", "c"]}`))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, a.DiffCount(c))
+	assert.Equal(t, 3, c.DiffCount(a))
+
+	// Empty maps and lists are leaves
+	empty, err := New([]byte(`{"a": {}}`))
+	assert.Equal(t, nil, err)
+	emptyList, err := New([]byte(`{"a": []}`))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, empty.DiffCount(empty))
+	assert.Equal(t, 1, empty.DiffCount(emptyList))
+	assert.Equal(t, 1, NewNode().DiffCount(&Node{[]interface{}{}}))
+	// The empty root map is a leaf that is not present in the other document
+	assert.Equal(t, 2, empty.DiffCount(NewNode()))
+}
+
+func TestEqualsApprox(t *testing.T) {
+	// Use variables, since constant expressions are evaluated exactly
+	a, b := 0.1, 0.2
+	computed := &Node{map[string]interface{}{"total": a + b, "items": []interface{}{1.0, "a"}}}
+	parsed, err := New([]byte(`{"total": 0.3, "items": [1, "a"]}`))
+	assert.Equal(t, nil, err)
+
+	assert.Equal(t, false, computed.Equals(parsed))
+	assert.Equal(t, true, computed.EqualsApprox(parsed, 1e-9))
+	assert.Equal(t, false, computed.EqualsApprox(parsed, 1e-20))
+
+	// Everything else is compared exactly
+	other, err := New([]byte(`{"total": 0.3, "items": [1, "b"]}`))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, computed.EqualsApprox(other, 1e-9))
+}
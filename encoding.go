@@ -0,0 +1,140 @@
+package jpath
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Byte order marks for the Unicode encodings that are detected by toUTF8
+var (
+	bomUTF8    = []byte{0xef, 0xbb, 0xbf}
+	bomUTF16LE = []byte{0xff, 0xfe}
+	bomUTF16BE = []byte{0xfe, 0xff}
+)
+
+// NewFromReaderEncoding returns a *Node by decoding from an io.Reader, like NewFromReader,
+// but UTF-16 data (little or big endian) is converted to UTF-8 first, and a byte order mark
+// is removed. The encoding is detected by the byte order mark, or if there is none, by the
+// first two bytes, since a JSON document always starts with an ASCII character.
+func NewFromReaderEncoding(r io.Reader) (*Node, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data, err = toUTF8(data)
+	if err != nil {
+		return nil, err
+	}
+	return New(data)
+}
+
+// toUTF8 detects if the given data is UTF-16 and converts it to UTF-8.
+// A byte order mark is removed.
+func toUTF8(data []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(data, bomUTF8):
+		return data[len(bomUTF8):], nil
+	case bytes.HasPrefix(data, bomUTF16LE):
+		return decodeUTF16(data[len(bomUTF16LE):], binary.LittleEndian)
+	case bytes.HasPrefix(data, bomUTF16BE):
+		return decodeUTF16(data[len(bomUTF16BE):], binary.BigEndian)
+	case len(data) >= 2 && data[0] != 0 && data[1] == 0:
+		return decodeUTF16(data, binary.LittleEndian)
+	case len(data) >= 2 && data[0] == 0 && data[1] != 0:
+		return decodeUTF16(data, binary.BigEndian)
+	}
+	return data, nil
+}
+
+// decodeUTF16 converts UTF-16 data with the given byte order to UTF-8
+func decodeUTF16(data []byte, order binary.ByteOrder) ([]byte, error) {
+	if len(data)%2 != 0 {
+		return nil, errors.New("UTF-16 data with an odd number of bytes")
+	}
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = order.Uint16(data[i*2:])
+	}
+	return []byte(string(utf16.Decode(units))), nil
+}
+
+// skipBOM returns an io.Reader that skips a leading UTF-8 byte order mark
+func skipBOM(r io.Reader) io.Reader {
+	br := bufio.NewReader(r)
+	if b, err := br.Peek(len(bomUTF8)); err == nil && bytes.Equal(b, bomUTF8) {
+		br.Discard(len(bomUTF8))
+	}
+	return br
+}
+
+// IsASCII checks if all strings in the document, including map keys,
+// only contain ASCII characters
+func (j *Node) IsASCII() bool {
+	return isASCII(j.data)
+}
+
+// isASCII checks if all strings and map keys within the given value only contain ASCII characters
+func isASCII(v interface{}) bool {
+	switch v := v.(type) {
+	case *Node:
+		return isASCII(v.data)
+	case string:
+		return asciiString(v)
+	case map[string]interface{}:
+		for key, val := range v {
+			if !asciiString(key) || !isASCII(val) {
+				return false
+			}
+		}
+	case []interface{}:
+		for _, val := range v {
+			if !isASCII(val) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// asciiString checks if the given string only contains ASCII characters
+func asciiString(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// ASCIIJSON returns its marshaled data as `[]byte`, like JSON, but with all
+// characters that are not ASCII escaped as \uXXXX, using UTF-16 surrogate pairs
+// for characters outside of the Basic Multilingual Plane, like most emojis
+func (j *Node) ASCIIJSON() ([]byte, error) {
+	data, err := j.JSON()
+	if err != nil {
+		return []byte{}, err
+	}
+	if asciiString(string(data)) {
+		return data, nil
+	}
+	// Characters that are not ASCII can only be within strings in the JSON data
+	var buf bytes.Buffer
+	for _, r := range string(data) {
+		if r < utf8.RuneSelf {
+			buf.WriteRune(r)
+			continue
+		}
+		if r1, r2 := utf16.EncodeRune(r); r1 != utf8.RuneError {
+			fmt.Fprintf(&buf, `\u%04x\u%04x`, r1, r2)
+			continue
+		}
+		fmt.Fprintf(&buf, `\u%04x`, r)
+	}
+	return buf.Bytes(), nil
+}
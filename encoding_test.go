@@ -0,0 +1,103 @@
+package jpath
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/bmizerany/assert"
+)
+
+// encodeUTF16 converts the given string to UTF-16 with the given byte order and byte order mark
+func encodeUTF16(s string, order binary.ByteOrder, bom []byte) []byte {
+	data := append([]byte{}, bom...)
+	for _, unit := range utf16.Encode([]rune(s)) {
+		b := make([]byte, 2)
+		order.PutUint16(b, unit)
+		data = append(data, b...)
+	}
+	return data
+}
+
+func TestNewFromReaderEncoding(t *testing.T) {
+	const document = `{"name": "Åse", "emoji": "😀"}`
+	inputs := [][]byte{
+		[]byte(document),
+		append(append([]byte{}, bomUTF8...), document...),
+		encodeUTF16(document, binary.LittleEndian, bomUTF16LE),
+		encodeUTF16(document, binary.BigEndian, bomUTF16BE),
+		encodeUTF16(document, binary.LittleEndian, nil),
+		encodeUTF16(document, binary.BigEndian, nil),
+	}
+	for _, input := range inputs {
+		js, err := NewFromReaderEncoding(bytes.NewReader(input))
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "Åse", js.Get("name").String())
+		assert.Equal(t, "😀", js.Get("emoji").String())
+	}
+
+	_, err := NewFromReaderEncoding(bytes.NewReader(append(append([]byte{}, bomUTF16LE...), '{')))
+	assert.NotEqual(t, nil, err)
+}
+
+func TestBOM(t *testing.T) {
+	document := []byte(`{"a": [1, "b"]}`)
+	withBOM := append(append([]byte{}, bomUTF8...), document...)
+
+	js, err := New(document)
+	assert.Equal(t, nil, err)
+	jsBOM, err := New(withBOM)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, js, jsBOM)
+
+	jsBOM, err = NewFromReader(bytes.NewReader(withBOM))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, js, jsBOM)
+
+	js, err = NewNumber(document)
+	assert.Equal(t, nil, err)
+	jsBOM, err = NewFromReaderNumber(bytes.NewReader(withBOM))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, js, jsBOM)
+
+	// A byte order mark and nothing else
+	js, err = New(bomUTF8)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, len(js.List()))
+}
+
+func TestIsASCII(t *testing.T) {
+	js, err := New([]byte(`{"name": "Bob", "tags": ["a", "b"], "age": 42}`))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, js.IsASCII())
+
+	js, err = New([]byte(`{"name": "Bob", "tags": ["a", "blåbær"]}`))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, js.IsASCII())
+
+	js, err = New([]byte(`{"navn": "Bob", "år": 42}`))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, js.IsASCII())
+}
+
+func TestASCIIJSON(t *testing.T) {
+	js, err := New([]byte(`{"emoji": "😀", "bmp": "é", "plain": "abc", "blåbær": "\n"}`))
+	assert.Equal(t, nil, err)
+
+	data, err := js.ASCIIJSON()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `{"bl\u00e5b\u00e6r":"\n","bmp":"\u00e9","emoji":"\ud83d\ude00","plain":"abc"}`, string(data))
+
+	// The escaped JSON is equal to the original
+	decoded, err := New(data)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, js, decoded)
+	assert.Equal(t, false, decoded.IsASCII())
+
+	// ASCII data is not changed
+	plain := &Node{map[string]interface{}{"a": "b"}}
+	data, err = plain.ASCIIJSON()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `{"a":"b"}`, string(data))
+}
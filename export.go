@@ -0,0 +1,132 @@
+package jpath
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ToEnv flattens the document into environment variable style names and values,
+// like PREFIX_DATABASE_HOST=localhost for {"database": {"host": "localhost"}}.
+// Keys are uppercased and joined with underscores, and list indexes become numbers.
+// If the prefix is empty, the names start with the first key.
+// Map keys are visited in sorted order, so if several keys give the same name,
+// like "log-level" and "log_level", the value for the last key in sorted order is used.
+func (j *Node) ToEnv(prefix string) map[string]string {
+	env := make(map[string]string)
+	toEnv(env, envName(prefix), j.data)
+	return env
+}
+
+// toEnv adds the given value to the env map, recursively, using the given name as a prefix
+func toEnv(env map[string]string, name string, v interface{}) {
+	switch v := v.(type) {
+	case *Node:
+		toEnv(env, name, v.data)
+	case map[string]interface{}:
+		for _, key := range (&Node{v}).Keys() {
+			toEnv(env, joinEnvName(name, envName(key)), v[key])
+		}
+	case []interface{}:
+		for i, val := range v {
+			toEnv(env, joinEnvName(name, strconv.Itoa(i)), val)
+		}
+	default:
+		env[name] = scalarString(v)
+	}
+}
+
+// joinEnvName joins two parts of an environment variable name with an underscore
+func joinEnvName(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "_" + name
+}
+
+// envName uppercases the given string and replaces characters that are not
+// letters or digits with underscores, so that it can be used as an environment variable name
+func envName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToUpper(r)
+		}
+		return '_'
+	}, s)
+}
+
+// QueryValues converts a flat map into url.Values, for use in query strings.
+// Lists of strings, numbers, booleans or nulls become repeated parameters.
+// Returns an error if the node is not a map, or if it contains nested maps or lists.
+func (j *Node) QueryValues() (url.Values, error) {
+	m, ok := j.CheckMap()
+	if !ok {
+		return nil, errors.New("Can only convert a map to query values. Not a map: " + j.Info())
+	}
+	values := make(url.Values)
+	for key, val := range m {
+		if l, ok := val.([]interface{}); ok {
+			for _, elem := range l {
+				if !isScalar(elem) {
+					return nil, errors.New("Can not convert a nested value to query values: " + key)
+				}
+				values.Add(key, scalarString(elem))
+			}
+			continue
+		}
+		if !isScalar(val) {
+			return nil, errors.New("Can not convert a nested value to query values: " + key)
+		}
+		values.Set(key, scalarString(val))
+	}
+	return values, nil
+}
+
+// TemplateData returns a copy of the data that is suitable for text/template
+// and html/template. json.Number values are converted to int64 if they are
+// integers, or to float64 if not, so that they can be used in arithmetic.
+func (j *Node) TemplateData() interface{} {
+	return templateData(j.data)
+}
+
+// ToStringMap returns a copy of a map as a map[string]interface{}, with json.Number
+// values converted in the same way as for TemplateData. Useful for passing the data
+// on to configuration libraries. Returns an error if the node is not a map.
+func (j *Node) ToStringMap() (map[string]interface{}, error) {
+	if _, ok := j.CheckMap(); !ok {
+		return nil, errors.New("Can only convert a map. Not a map: " + j.Info())
+	}
+	return templateData(j.data).(map[string]interface{}), nil
+}
+
+// templateData returns a copy of the given value, with json.Number values converted
+func templateData(v interface{}) interface{} {
+	switch v := v.(type) {
+	case *Node:
+		return templateData(v.data)
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			m[key] = templateData(val)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, val := range v {
+			l[i] = templateData(val)
+		}
+		return l
+	}
+	return v
+}
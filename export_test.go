@@ -0,0 +1,96 @@
+package jpath
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"text/template"
+
+	"github.com/bmizerany/assert"
+)
+
+func TestToEnv(t *testing.T) {
+	js, err := New([]byte(`{
+		"database": {"host": "localhost", "port": 5432, "ssl": false},
+		"servers": ["a.example.com", "b.example.com"],
+		"log-level": "debug",
+		"timeout": 1.5,
+		"extra": null
+	}`))
+	assert.Equal(t, nil, err)
+
+	assert.Equal(t, map[string]string{
+		"APP_DATABASE_HOST": "localhost",
+		"APP_DATABASE_PORT": "5432",
+		"APP_DATABASE_SSL":  "false",
+		"APP_SERVERS_0":     "a.example.com",
+		"APP_SERVERS_1":     "b.example.com",
+		"APP_LOG_LEVEL":     "debug",
+		"APP_TIMEOUT":       "1.5",
+		"APP_EXTRA":         "",
+	}, js.ToEnv("APP"))
+
+	assert.Equal(t, "localhost", js.ToEnv("")["DATABASE_HOST"])
+
+	// The last key in sorted order wins when several keys give the same name
+	collision, err := New([]byte(`{"log_level": "c", "log-level": "a", "log.level": "b"}`))
+	assert.Equal(t, nil, err)
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, map[string]string{"LOG_LEVEL": "c"}, collision.ToEnv(""))
+	}
+}
+
+func TestQueryValues(t *testing.T) {
+	js, err := New([]byte(`{"q": "json path", "page": 2, "tag": ["a", "b"], "exact": true}`))
+	assert.Equal(t, nil, err)
+
+	values, err := js.QueryValues()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "json path", values.Get("q"))
+	assert.Equal(t, "2", values.Get("page"))
+	assert.Equal(t, []string{"a", "b"}, values["tag"])
+	assert.Equal(t, "exact=true&page=2&q=json+path&tag=a&tag=b", values.Encode())
+
+	js, err = New([]byte(`{"nested": {"a": 1}}`))
+	assert.Equal(t, nil, err)
+	_, err = js.QueryValues()
+	assert.NotEqual(t, nil, err)
+
+	_, err = js.Get("nested").Get("a").QueryValues()
+	assert.NotEqual(t, nil, err)
+}
+
+func TestTemplateData(t *testing.T) {
+	js, err := NewNumber([]byte(`{"count": 3, "price": 1.5, "user": {"name": "Bob", "roles": ["admin", "dev"]}}`))
+	assert.Equal(t, nil, err)
+
+	tmpl := template.Must(template.New("test").Parse(
+		`{{.count}} {{.price}} {{.user.name}} {{index .user.roles 1}} {{if gt .count 2}}many{{end}}`))
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, js.TemplateData())
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "3 1.5 Bob dev many", buf.String())
+
+	// The original document is not modified
+	_, ok := js.Get("count").Interface().(json.Number)
+	assert.Equal(t, true, ok)
+}
+
+func TestToStringMap(t *testing.T) {
+	js, err := NewNumber([]byte(`{"port": 8080, "ratio": 0.75, "db": {"pool": {"max": 10, "timeout": 2.5}}, "ids": [1, 2]}`))
+	assert.Equal(t, nil, err)
+
+	m, err := js.ToStringMap()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int64(8080), m["port"])
+	assert.Equal(t, 0.75, m["ratio"])
+	pool := m["db"].(map[string]interface{})["pool"].(map[string]interface{})
+	assert.Equal(t, int64(10), pool["max"])
+	assert.Equal(t, 2.5, pool["timeout"])
+	assert.Equal(t, []interface{}{int64(1), int64(2)}, m["ids"])
+
+	list, err := NewNumber([]byte(`[1, 2]`))
+	assert.Equal(t, nil, err)
+	_, err = list.ToStringMap()
+	assert.NotEqual(t, nil, err)
+}
@@ -0,0 +1,174 @@
+package jpath
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// Hash returns a SHA-256 hash of the canonical JSON of the document, as a hex string.
+// The hash does not depend on the order of map keys or on how numbers are written,
+// so that documents with the same contents, like {"a": 1.0, "b": 2} and {"b": 2, "a": 1},
+// have the same hash.
+func (j *Node) Hash() (string, error) {
+	data, err := j.CanonicalJSON()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// CanonicalJSON returns the document as canonical JSON, as specified by
+// RFC 8785 (JSON Canonicalization Scheme). Map keys are sorted by their
+// UTF-16 code units, numbers are formatted like in ECMAScript and there is
+// no whitespace. This is useful for hashing and signing documents.
+func (j *Node) CanonicalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeCanonical(&buf, j.data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeCanonical writes the given value as canonical JSON
+func writeCanonical(buf *bytes.Buffer, v interface{}) error {
+	switch v := v.(type) {
+	case *Node:
+		return writeCanonical(buf, v.data)
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	case string:
+		writeCanonicalString(buf, v)
+	case json.Number, float32, float64, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		f, ok := (&Node{v}).CheckFloat64()
+		if !ok {
+			return fmt.Errorf("invalid number: %v", v)
+		}
+		s, err := canonicalNumber(f)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(s)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(a, b int) bool {
+			return lessUTF16(keys[a], keys[b])
+		})
+		buf.WriteByte('{')
+		for i, key := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeCanonicalString(buf, key)
+			buf.WriteByte(':')
+			if err := writeCanonical(buf, v[key]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, val := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeCanonical(buf, val); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	default:
+		// Convert other types to basic JSON types first
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		n, err := NewNumber(data)
+		if err != nil {
+			return err
+		}
+		return writeCanonical(buf, n.data)
+	}
+	return nil
+}
+
+// writeCanonicalString writes the given string as a canonical JSON string, where only
+// quotation marks, backslashes and control characters are escaped
+func writeCanonicalString(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(buf, `\u%04x`, r)
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	buf.WriteByte('"')
+}
+
+// canonicalNumber formats a number in the same way as ECMAScript does
+func canonicalNumber(f float64) (string, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "", errors.New("can not represent NaN or infinity as JSON")
+	}
+	if f == 0 {
+		// Also for negative zero
+		return "0", nil
+	}
+	sign := ""
+	if f < 0 {
+		f, sign = -f, "-"
+	}
+	format := byte('e')
+	if f < 1e21 && f >= 1e-6 {
+		format = 'f'
+	}
+	s := strconv.FormatFloat(f, format, -1, 64)
+	// Go writes exponents like "1e+09", while ECMAScript writes "1e+9"
+	if i := strings.IndexByte(s, 'e'); i > 0 && s[i+2] == '0' {
+		s = s[:i+2] + s[i+3:]
+	}
+	return sign + s, nil
+}
+
+// lessUTF16 compares two strings by their UTF-16 code units
+func lessUTF16(a, b string) bool {
+	ua, ub := utf16.Encode([]rune(a)), utf16.Encode([]rune(b))
+	for i := 0; i < len(ua) && i < len(ub); i++ {
+		if ua[i] != ub[i] {
+			return ua[i] < ub[i]
+		}
+	}
+	return len(ua) < len(ub)
+}
@@ -0,0 +1,58 @@
+package jpath
+
+import (
+	"math"
+	"testing"
+
+	"github.com/bmizerany/assert"
+)
+
+func TestHash(t *testing.T) {
+	a, err := New([]byte(`{"a": 1.0, "b": [1, 2, {"c": "d", "e": 10}]}`))
+	assert.Equal(t, nil, err)
+	b, err := NewNumber([]byte(`{"b": [1.0, 2e0, {"e": 1e1, "c": "d"}], "a": 1}`))
+	assert.Equal(t, nil, err)
+
+	hashA, err := a.Hash()
+	assert.Equal(t, nil, err)
+	hashB, err := b.Hash()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, hashA, hashB)
+	assert.Equal(t, 64, len(hashA))
+
+	b.Set("a", 2)
+	hashB, err = b.Hash()
+	assert.Equal(t, nil, err)
+	assert.NotEqual(t, hashA, hashB)
+}
+
+func TestCanonicalJSON(t *testing.T) {
+	// Examples from RFC 8785
+	js, err := NewNumber([]byte(`{
+		"numbers": [333333333.33333329, 1E30, 4.50, 2e-3, 0.000000000000000000000000001],
+		"string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/",
+		"literals": [null, true, false]
+	}`))
+	assert.Equal(t, nil, err)
+	data, err := js.CanonicalJSON()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`, string(data))
+
+	js, err = New([]byte(`{
+		"\u20ac": "Euro Sign",
+		"\r": "Carriage Return",
+		"\ufb33": "Hebrew Letter Dalet With Dagesh",
+		"1": "One",
+		"\ud83d\ude00": "Emoji: Grinning Face",
+		"\u0080": "Control",
+		"\u00f6": "Latin Small Letter O With Diaeresis"
+	}`))
+	assert.Equal(t, nil, err)
+	data, err = js.CanonicalJSON()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "{\"\\r\":\"Carriage Return\",\"1\":\"One\",\"\u0080\":\"Control\",\"ö\":\"Latin Small Letter O With Diaeresis\",\"€\":\"Euro Sign\",\"😀\":\"Emoji: Grinning Face\",\"\ufb33\":\"Hebrew Letter Dalet With Dagesh\"}", string(data))
+
+	js.Set("nan", math.NaN())
+	_, err = js.CanonicalJSON()
+	assert.NotEqual(t, nil, err)
+}
@@ -1,36 +1,107 @@
 package jpath
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
 	"errors"
+	"io"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 )
 
 var (
 	// ErrSpecificNode is for when retrieving a node does not return a specific key/value, but perhaps a map
 	ErrSpecificNode = errors.New("could not find a specific node that matched the given path")
+
+	// ErrUnsavedChanges is for when the file can not be reloaded, since the JSON data has been modified
+	ErrUnsavedChanges = errors.New("the JSON data has unsaved changes")
 )
 
-// JFile represents a JSON file and contains the filename and root node
+// JFile represents a JSON file and contains the filename and root node.
+// The methods of JFile can be used concurrently, since they lock the mutex
+// before accessing the root node, but nodes returned by GetNode are not protected.
 type JFile struct {
 	filename string
 	rootnode *Node
 	rw       *sync.RWMutex
 	pretty   bool // Indent JSON output prettily
+	dirty    bool // The JSON data has been modified since it was read or written
 }
 
-// NewFile will read the given filename and return a JFile struct
-func NewFile(filename string) (*JFile, error) {
+// readFile reads the given file, and decompresses it if it is gzipped
+func readFile(filename string) ([]byte, error) {
 	data, err := os.ReadFile(filename)
 	if err != nil {
 		return nil, err
 	}
+	// Check for the gzip magic bytes
+	if !bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		return data, nil
+	}
+	gr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer gr.Close()
+	return io.ReadAll(gr)
+}
+
+// NewFromFile reads the given JSON file and returns a *Node.
+// Gzipped files are decompressed transparently.
+func NewFromFile(filename string) (*Node, error) {
+	data, err := readFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return New(data)
+}
+
+// NewFromFiles reads the given JSON files and merges them in order, so that
+// values from later files replace values from earlier files. Maps are merged
+// recursively. This is useful for layered configuration files.
+func NewFromFiles(filenames ...string) (*Node, error) {
+	merged := NewNode()
+	for _, filename := range filenames {
+		js, err := NewFromFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		merged.Merge(js)
+	}
+	return merged, nil
+}
+
+// NewFromGlob reads and merges all JSON files that match the given glob pattern,
+// like "conf.d/*.json", in the same way as NewFromFiles. The files are merged in
+// sorted order. If no files match, an empty map is returned.
+func NewFromGlob(pattern string) (*Node, error) {
+	filenames, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(filenames)
+	return NewFromFiles(filenames...)
+}
+
+// NewFile will read the given filename and return a JFile struct.
+// Gzipped files are decompressed transparently, and if the filename
+// ends with ".gz", the data is also gzipped when writing.
+func NewFile(filename string) (*JFile, error) {
+	data, err := readFile(filename)
+	if err != nil {
+		return nil, err
+	}
 	js, err := New(data)
 	if err != nil {
 		return nil, err
 	}
 	rw := &sync.RWMutex{}
-	return &JFile{filename, js, rw, true}, nil
+	return &JFile{filename, js, rw, true, false}, nil
 }
 
 // GetFilename returns the current filename
@@ -44,14 +115,17 @@ func (jf *JFile) SetPretty(pretty bool) {
 	jf.pretty = pretty
 }
 
-// SetRW allows a different mutex to be used when writing the JSON documents to file
+// SetRW allows a different mutex to be used when reading and modifying the JSON data,
+// and when writing the JSON documents to file
 func (jf *JFile) SetRW(rw *sync.RWMutex) {
 	jf.rw = rw
 }
 
 // GetNode tries to find the JSON node that corresponds to the given JSON path
 func (jf *JFile) GetNode(JSONpath string) (*Node, error) {
+	jf.rw.RLock()
 	node, _, err := jf.rootnode.GetNodes(JSONpath)
+	jf.rw.RUnlock()
 	if node == NilNode {
 		return NilNode, errors.New("nil node")
 	}
@@ -69,6 +143,10 @@ func (jf *JFile) GetString(JSONpath string) (string, error) {
 
 // SetString will change the value of the key that the given JSON path points to
 func (jf *JFile) SetString(JSONpath, value string) error {
+	// The data is written while the mutex is locked, so that the changes
+	// are written to the file in the same order as they are made
+	jf.rw.Lock()
+	defer jf.rw.Unlock()
 	_, parentNode, err := jf.rootnode.GetNodes(JSONpath)
 	if err != nil {
 		return err
@@ -80,60 +158,202 @@ func (jf *JFile) SetString(JSONpath, value string) error {
 
 	// Set the string
 	m[lastpart(JSONpath)] = value
+	jf.dirty = true
 
 	newdata, err := jf.rootnode.PrettyJSON()
 	if err != nil {
 		return err
 	}
 
-	return jf.Write(newdata)
+	return jf.write(newdata)
 }
 
-// Write writes the current JSON data to the file
+// Write writes the given JSON data to the file.
+// The data is gzipped if the filename ends with ".gz".
 func (jf *JFile) Write(data []byte) error {
 	jf.rw.Lock()
 	defer jf.rw.Unlock()
-	return os.WriteFile(jf.filename, data, 0666)
+	return jf.write(data)
 }
 
-// AddJSON adds JSON data at the given JSON path. If pretty is true, the JSON is indented.
-func (jf *JFile) AddJSON(JSONpath string, JSONdata []byte) error {
-	if err := jf.rootnode.AddJSON(JSONpath, JSONdata); err != nil {
+// write writes the given JSON data to the file, and marks the JSON data as not modified.
+// The mutex must be locked by the caller.
+func (jf *JFile) write(data []byte) error {
+	if strings.HasSuffix(jf.filename, ".gz") {
+		var buf bytes.Buffer
+		gw := gzip.NewWriter(&buf)
+		if _, err := gw.Write(data); err != nil {
+			return err
+		}
+		if err := gw.Close(); err != nil {
+			return err
+		}
+		data = buf.Bytes()
+	}
+	if err := os.WriteFile(jf.filename, data, 0666); err != nil {
 		return err
 	}
+	jf.dirty = false
+	return nil
+}
+
+// MarkDirty marks the JSON data as modified, so that it is written by Save.
+// This is needed if the JSON data is modified directly, through a *Node from GetNode.
+func (jf *JFile) MarkDirty() {
+	jf.rw.Lock()
+	jf.dirty = true
+	jf.rw.Unlock()
+}
+
+// Dirty returns true if the JSON data has been modified since it was read or written
+func (jf *JFile) Dirty() bool {
+	jf.rw.RLock()
+	defer jf.rw.RUnlock()
+	return jf.dirty
+}
+
+// Save writes the current JSON data to the file, but only if it has been modified
+func (jf *JFile) Save() error {
+	jf.rw.Lock()
+	defer jf.rw.Unlock()
+	if !jf.dirty {
+		return nil
+	}
+	data, err := jf.marshal()
+	if err != nil {
+		return err
+	}
+	return jf.write(data)
+}
+
+// marshal returns the current JSON data, indented if the pretty flag is set.
+// The mutex must be locked by the caller.
+func (jf *JFile) marshal() ([]byte, error) {
 	// Use the correct JSON function, depending on the pretty parameter
 	JSON := jf.rootnode.JSON
 	if jf.pretty {
 		JSON = jf.rootnode.PrettyJSON
 	}
-	data, err := JSON()
+	return JSON()
+}
+
+// WriteToWriter writes the current JSON data to the given io.Writer instead of to the file.
+// The JSON is indented if the pretty flag is set.
+func (jf *JFile) WriteToWriter(w io.Writer) error {
+	jf.rw.RLock()
+	defer jf.rw.RUnlock()
+	data, err := jf.marshal()
 	if err != nil {
 		return err
 	}
-	return jf.Write(data)
+	_, err = w.Write(data)
+	return err
+}
+
+// AddJSON adds JSON data at the given JSON path. If pretty is true, the JSON is indented.
+func (jf *JFile) AddJSON(JSONpath string, JSONdata []byte) error {
+	jf.rw.Lock()
+	defer jf.rw.Unlock()
+	if err := jf.rootnode.AddJSON(JSONpath, JSONdata); err != nil {
+		return err
+	}
+	jf.dirty = true
+	data, err := jf.marshal()
+	if err != nil {
+		return err
+	}
+	return jf.write(data)
 }
 
 // DelKey removes a key from the map that the JSON path leads to.
 // Returns ErrKeyNotFound if the key is not found.
 func (jf *JFile) DelKey(JSONpath string) error {
+	jf.rw.Lock()
+	defer jf.rw.Unlock()
 	err := jf.rootnode.DelKey(JSONpath)
 	if err != nil {
 		return err
 	}
-	// Use the correct JSON function, depending on the pretty parameter
-	JSON := jf.rootnode.JSON
-	if jf.pretty {
-		JSON = jf.rootnode.PrettyJSON
+	jf.dirty = true
+	data, err := jf.marshal()
+	if err != nil {
+		return err
 	}
-	data, err := JSON()
+	return jf.write(data)
+}
+
+// Reload reads the file again, replacing the current JSON data.
+// Returns ErrUnsavedChanges if the JSON data has been modified but not written.
+func (jf *JFile) Reload() error {
+	data, err := readFile(jf.filename)
 	if err != nil {
 		return err
 	}
-	return jf.Write(data)
+	js, err := New(data)
+	if err != nil {
+		return err
+	}
+	jf.rw.Lock()
+	defer jf.rw.Unlock()
+	if jf.dirty {
+		return ErrUnsavedChanges
+	}
+	jf.rootnode = js
+	return nil
+}
+
+// DefaultWatchInterval is how often Watch checks if the file has changed,
+// if the given interval is 0 or negative
+const DefaultWatchInterval = 500 * time.Millisecond
+
+// Watch checks the file for changes at the given interval, until the context
+// is cancelled, and then returns the error from the context. When the file has
+// changed, and has not changed again for a while, it is reloaded and onChange
+// is called. If the file can not be reloaded, for instance because it contains
+// invalid JSON or the JSON data has unsaved changes, onChange is not called.
+// If interval is 0 or negative, DefaultWatchInterval is used.
+func (jf *JFile) Watch(ctx context.Context, interval time.Duration, onChange func(*JFile)) error {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	fi, err := os.Stat(jf.filename)
+	if err != nil {
+		return err
+	}
+	lastMod, lastSize := fi.ModTime(), fi.Size()
+	pending := false
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		fi, err := os.Stat(jf.filename)
+		if err != nil {
+			// The file may be in the process of being replaced
+			continue
+		}
+		if !fi.ModTime().Equal(lastMod) || fi.Size() != lastSize {
+			// Wait until the file has stopped changing before reloading it
+			lastMod, lastSize = fi.ModTime(), fi.Size()
+			pending = true
+			continue
+		}
+		if pending {
+			pending = false
+			if jf.Reload() == nil {
+				onChange(jf)
+			}
+		}
+	}
 }
 
 // JSON returns the current JSON data, as prettily formatted JSON
 func (jf *JFile) JSON() ([]byte, error) {
+	jf.rw.RLock()
+	defer jf.rw.RUnlock()
 	return jf.rootnode.PrettyJSON()
 }
 
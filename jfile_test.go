@@ -1,9 +1,17 @@
 package jpath
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
 	"github.com/bmizerany/assert"
+	"io"
 	"os"
+	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestAddFile(t *testing.T) {
@@ -46,7 +54,7 @@ func TestGetFile(t *testing.T) {
 	assert.NotEqual(t, nil, js)
 
 	node := js.GetNode("[1]")
-	assert.Equal(t, NilNode, node)
+	assert.Equal(t, "2", node.Get("x").String())
 
 	found, err = GetString(tmpfile, "[1].x")
 	assert.Equal(t, nil, err)
@@ -60,3 +68,231 @@ func TestGetFile(t *testing.T) {
 	assert.Equal(t, nil, err)
 	assert.Equal(t, found, "2")
 }
+
+func TestGzipFile(t *testing.T) {
+	documentJSON := []byte(`[{"x":"7","y":"15"},{"x":"2","y":"3"}]`)
+	tmpfile := "/tmp/___jpath.json.gz"
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	_, err := gw.Write(documentJSON)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, gw.Close())
+	err = os.WriteFile(tmpfile, buf.Bytes(), 0666)
+	assert.Equal(t, nil, err)
+	defer os.Remove(tmpfile)
+
+	js, err := NewFromFile(tmpfile)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "2", js.GetNode("x[1].x").String())
+
+	err = SetString(tmpfile, "x[1].x", "42")
+	assert.Equal(t, nil, err)
+
+	// The file should still be gzipped
+	fileData, err := os.ReadFile(tmpfile)
+	assert.Equal(t, nil, err)
+	gr, err := gzip.NewReader(bytes.NewReader(fileData))
+	assert.Equal(t, nil, err)
+	data, err := io.ReadAll(gr)
+	assert.Equal(t, nil, err)
+	js, err = New(data)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "42", js.GetNode("x[1].x").String())
+}
+
+func TestWriteToWriter(t *testing.T) {
+	documentJSON := []byte(`[{"x":"7","y":"15"}]`)
+	tmpfile := "/tmp/___jpath.json"
+	err := os.WriteFile(tmpfile, documentJSON, 0666)
+	assert.Equal(t, nil, err)
+	defer os.Remove(tmpfile)
+
+	jf, err := NewFile(tmpfile)
+	assert.Equal(t, nil, err)
+	for _, pretty := range []bool{true, false} {
+		jf.SetPretty(pretty)
+		err = jf.AddJSON("x", []byte(`{"x":"2","y":"3"}`))
+		assert.Equal(t, nil, err)
+
+		var buf bytes.Buffer
+		err = jf.WriteToWriter(&buf)
+		assert.Equal(t, nil, err)
+
+		fileData, err := os.ReadFile(tmpfile)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, string(fileData), buf.String())
+	}
+}
+
+func TestNewFromFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"base.json":  `{"server": {"host": "localhost", "port": 80}, "debug": false}`,
+		"env.json":   `{"server": {"port": 8080}, "name": "env"}`,
+		"local.json": `{"debug": true, "server": {"tls": true}}`,
+	}
+	var filenames []string
+	for _, name := range []string{"base.json", "env.json", "local.json"} {
+		filename := filepath.Join(dir, name)
+		err := os.WriteFile(filename, []byte(files[name]), 0666)
+		assert.Equal(t, nil, err)
+		filenames = append(filenames, filename)
+	}
+
+	js, err := NewFromFiles(filenames...)
+	assert.Equal(t, nil, err)
+	data, err := js.JSON()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `{"debug":true,"name":"env","server":{"host":"localhost","port":8080,"tls":true}}`, string(data))
+
+	_, err = NewFromFiles(filepath.Join(dir, "missing.json"))
+	assert.NotEqual(t, nil, err)
+}
+
+func TestNewFromGlob(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"20-b.json": `{"name": "b", "b": true}`,
+		"10-a.json": `{"name": "a", "a": true}`,
+		"30-c.json": `{"name": "c"}`,
+		"ignored":   `{"name": "ignored"}`,
+	}
+	for name, contents := range files {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0666)
+		assert.Equal(t, nil, err)
+	}
+
+	js, err := NewFromGlob(filepath.Join(dir, "*.json"))
+	assert.Equal(t, nil, err)
+	data, err := js.JSON()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `{"a":true,"b":true,"name":"c"}`, string(data))
+
+	js, err = NewFromGlob(filepath.Join(dir, "*.missing"))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, len(js.Map()))
+}
+
+func TestWatch(t *testing.T) {
+	tmpfile := filepath.Join(t.TempDir(), "watched.json")
+	err := os.WriteFile(tmpfile, []byte(`{"version": 1}`), 0666)
+	assert.Equal(t, nil, err)
+
+	jf, err := NewFile(tmpfile)
+	assert.Equal(t, nil, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	versions := make(chan string, 10)
+	done := make(chan error)
+	go func() {
+		done <- jf.Watch(ctx, 10*time.Millisecond, func(jf *JFile) {
+			node, _ := jf.GetNode("version")
+			versions <- node.Info()
+		})
+	}()
+
+	// Make sure that the modification time changes
+	time.Sleep(20 * time.Millisecond)
+	err = os.WriteFile(tmpfile, []byte(`{"version": 2}`), 0666)
+	assert.Equal(t, nil, err)
+	future := time.Now().Add(time.Second)
+	assert.Equal(t, nil, os.Chtimes(tmpfile, future, future))
+
+	select {
+	case version := <-versions:
+		assert.Equal(t, "Int: 2", version)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the file was not reloaded")
+	}
+
+	cancel()
+	assert.Equal(t, context.Canceled, <-done)
+
+	// An interval that is not positive falls back to the default interval
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, jf.Watch(ctx, 0, func(*JFile) {}))
+}
+
+func TestReload(t *testing.T) {
+	tmpfile := filepath.Join(t.TempDir(), "reload.json")
+	err := os.WriteFile(tmpfile, []byte(`{"version": 1}`), 0666)
+	assert.Equal(t, nil, err)
+
+	jf, err := NewFile(tmpfile)
+	assert.Equal(t, nil, err)
+	err = os.WriteFile(tmpfile, []byte(`{"version": 2}`), 0666)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, jf.Reload())
+	node, err := jf.GetNode("version")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, node.Int())
+
+	// Unsaved changes are not thrown away
+	root, err := jf.GetNode(".")
+	assert.Equal(t, nil, err)
+	root.Set("modified", true)
+	jf.MarkDirty()
+	assert.Equal(t, ErrUnsavedChanges, jf.Reload())
+	assert.Equal(t, true, jf.Dirty())
+	assert.Equal(t, nil, jf.Save())
+	assert.Equal(t, nil, jf.Reload())
+}
+
+func TestSave(t *testing.T) {
+	documentJSON := []byte(`{"x":"7","y":"15"}`)
+	tmpfile := filepath.Join(t.TempDir(), "save.json")
+	err := os.WriteFile(tmpfile, documentJSON, 0666)
+	assert.Equal(t, nil, err)
+
+	jf, err := NewFile(tmpfile)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, jf.Dirty())
+
+	// Nothing has changed, so the file should not be rewritten with indentation
+	assert.Equal(t, nil, jf.Save())
+	fileData, err := os.ReadFile(tmpfile)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, string(documentJSON), string(fileData))
+
+	// Modify the data directly, then save
+	node, err := jf.GetNode("x")
+	assert.Equal(t, nil, err)
+	node.Set("z", "42")
+	jf.MarkDirty()
+	assert.Equal(t, true, jf.Dirty())
+	assert.Equal(t, nil, jf.Save())
+	assert.Equal(t, false, jf.Dirty())
+	found, err := GetString(tmpfile, "x.z")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "42", found)
+
+	// SetString writes the file right away
+	assert.Equal(t, nil, jf.SetString("x.y", "16"))
+	assert.Equal(t, false, jf.Dirty())
+}
+
+func TestConcurrentWrites(t *testing.T) {
+	tmpfile := filepath.Join(t.TempDir(), "concurrent.json")
+	err := os.WriteFile(tmpfile, []byte(`[]`), 0666)
+	assert.Equal(t, nil, err)
+
+	jf, err := NewFile(tmpfile)
+	assert.Equal(t, nil, err)
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.Equal(t, nil, jf.AddJSON("x", []byte(fmt.Sprintf(`{"key%d": %d}`, i, i))))
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, false, jf.Dirty())
+
+	// The last write contains all the changes
+	js, err := NewFromFile(tmpfile)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 20, len(js.List()))
+}
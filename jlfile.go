@@ -0,0 +1,109 @@
+package jpath
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"sync"
+)
+
+// JLFile represents a JSON Lines file, where each line is a JSON document.
+// The file is read line by line, and is never loaded into memory all at once.
+type JLFile struct {
+	filename string
+	rw       *sync.RWMutex
+}
+
+// NewJLFile returns a *JLFile for the given filename. The file does not need
+// to exist, it is created by the first call to Append.
+func NewJLFile(filename string) *JLFile {
+	return &JLFile{filename, &sync.RWMutex{}}
+}
+
+// GetFilename returns the current filename
+func (jl *JLFile) GetFilename() string {
+	return jl.filename
+}
+
+// SetRW allows a different mutex to be used when reading and writing the file
+func (jl *JLFile) SetRW(rw *sync.RWMutex) {
+	jl.rw = rw
+}
+
+// Append adds the given node as a new line at the end of the file
+func (jl *JLFile) Append(n *Node) error {
+	data, err := n.JSON()
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	jl.rw.Lock()
+	defer jl.rw.Unlock()
+	f, err := os.OpenFile(jl.filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// eachLine calls fn for each line in the file that is not blank, until fn returns an error.
+// A file that does not exist is treated as an empty file. The mutex is only locked
+// while reading from the file, not while fn is running, so that fn may call Append.
+func (jl *JLFile) eachLine(fn func(line []byte) error) error {
+	jl.rw.RLock()
+	f, err := os.Open(jl.filename)
+	jl.rw.RUnlock()
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	for {
+		jl.rw.RLock()
+		line, err := r.ReadBytes('\n')
+		jl.rw.RUnlock()
+		if len(bytes.TrimSpace(line)) > 0 {
+			if err := fn(line); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// Range calls fn with each JSON document in the file, in order.
+// Blank lines are skipped. Stops and returns the error if a line contains
+// invalid JSON, or if fn returns an error. fn may call Append, but then
+// it depends on timing if Range also reaches the new lines.
+func (jl *JLFile) Range(fn func(n *Node) error) error {
+	return jl.eachLine(func(line []byte) error {
+		n, err := New(line)
+		if err != nil {
+			return err
+		}
+		return fn(n)
+	})
+}
+
+// Count returns the number of JSON documents in the file, without parsing them.
+// Blank lines are not counted.
+func (jl *JLFile) Count() (int, error) {
+	count := 0
+	err := jl.eachLine(func(line []byte) error {
+		count++
+		return nil
+	})
+	return count, err
+}
@@ -0,0 +1,65 @@
+package jpath
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bmizerany/assert"
+)
+
+func TestJLFile(t *testing.T) {
+	tmpfile := filepath.Join(t.TempDir(), "records.jsonl")
+	jl := NewJLFile(tmpfile)
+
+	// The file does not exist yet
+	count, err := jl.Count()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, count)
+
+	for _, record := range []string{`{"id": 1}`, `{"id": 2, "tags": ["a", "b"]}`, `{"id": 3}`} {
+		n, err := New([]byte(record))
+		assert.Equal(t, nil, err)
+		assert.Equal(t, nil, jl.Append(n))
+	}
+
+	count, err = jl.Count()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, count)
+
+	var ids []int
+	err = jl.Range(func(n *Node) error {
+		ids = append(ids, n.Get("id").Int())
+		return nil
+	})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []int{1, 2, 3}, ids)
+
+	// Stop at the first error
+	errStop := errors.New("stop")
+	visited := 0
+	err = jl.Range(func(n *Node) error {
+		visited++
+		return errStop
+	})
+	assert.Equal(t, errStop, err)
+	assert.Equal(t, 1, visited)
+
+	// Each record is on a line of its own
+	data, err := os.ReadFile(tmpfile)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "{\"id\":1}\n{\"id\":2,\"tags\":[\"a\",\"b\"]}\n{\"id\":3}\n", string(data))
+
+	// Append can be called from within Range
+	err = jl.Range(func(n *Node) error {
+		if n.Get("id").Int() == 1 {
+			return jl.Append(&Node{map[string]interface{}{"id": 4}})
+		}
+		return nil
+	})
+	assert.Equal(t, nil, err)
+	count, err = jl.Count()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 4, count)
+}
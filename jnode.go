@@ -1,4 +1,8 @@
 // Package jpath provides a way to search and manipulate JSON documents
+//
+// Getters like String and Int take an optional default value, which is returned
+// if the node does not have the expected type. If more than one default value
+// is given, only the first one is used.
 package jpath
 
 import (
@@ -7,10 +11,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
+	"math"
+	"math/big"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 // Version contains the version number. The API is stable within the same major version.
@@ -25,17 +32,27 @@ type (
 	NodeList []*Node
 	// NodeMap is a map of nodes
 	NodeMap map[string]*Node
+	// Pair is a key and the corresponding node, from a map
+	Pair struct {
+		Key   string
+		Value *Node
+	}
 )
 
 // NilNode is an empty node. Used when not finding nodes with Get.
+// NilNode is shared, so it is never modified.
 var (
 	NilNode        = &Node{nil}
 	ErrKeyNotFound = errors.New("key not found")
+	ErrNilNode     = errors.New("the nil node can not be modified")
 )
 
 // New returns a pointer to a new `Node` object
-// after unmarshaling `body` bytes
+// after unmarshaling `body` bytes. Numbers are decoded as float64.
+// Use NewNumber to decode numbers as json.Number instead.
+// A leading UTF-8 byte order mark is ignored.
 func New(body []byte) (*Node, error) {
+	body = bytes.TrimPrefix(body, bomUTF8)
 	if len(body) == 0 {
 		// Use an empty list if no data has been provided
 		body = []byte("[]")
@@ -78,6 +95,22 @@ func (j *Node) MustJSON() []byte {
 	return data
 }
 
+// CheckedJSON returns its marshaled data as `[]byte`, but first checks that
+// all values in the document can be encoded as JSON. The returned error
+// contains the JSON path to the first value that can not be encoded.
+func (j *Node) CheckedJSON() ([]byte, error) {
+	if err := checkJSON("x", j.data); err != nil {
+		return []byte{}, err
+	}
+	return j.JSON()
+}
+
+// PrecisionJSON returns its marshaled data as `[]byte`, with all numbers that
+// are not integers formatted with the given number of significant digits
+func (j *Node) PrecisionJSON(prec int) ([]byte, error) {
+	return json.Marshal(withPrecision(j.data, prec))
+}
+
 // PrettyJSON returns its marshaled data as `[]byte` with indentation
 func (j *Node) PrettyJSON() ([]byte, error) {
 	return json.MarshalIndent(&j.data, "", "  ")
@@ -98,6 +131,20 @@ func (j *Node) Set(key string, val interface{}) {
 	m[key] = val
 }
 
+// SetChecked is like Set, but returns an error instead of setting the value
+// if the value can not be encoded as JSON, or if the node is not a map.
+func (j *Node) SetChecked(key string, val interface{}) error {
+	m, ok := j.CheckMap()
+	if !ok {
+		return errors.New("Can only set a key in a map. Not a map: " + j.Info())
+	}
+	if err := checkJSON(keyPath("x", key), val); err != nil {
+		return err
+	}
+	m[key] = val
+	return nil
+}
+
 // SetBranch modifies `Node`, recursively checking/creating map keys for the supplied path,
 // and then finally writing in the value.
 func (j *Node) SetBranch(branch []string, val interface{}) {
@@ -152,11 +199,15 @@ func (j *Node) GetKey(key string) (*Node, bool) {
 
 // GetIndex returns a pointer to a new `Node` object
 // for `index` in its slice representation
-// and a bool identifying success or failure
+// and a bool identifying success or failure.
+// A negative index counts from the end, so that -1 is the last element.
 func (j *Node) GetIndex(index int) (*Node, bool) {
 	a, ok := j.CheckList()
 	if ok {
-		if len(a) > index {
+		if index < 0 {
+			index += len(a)
+		}
+		if index >= 0 && len(a) > index {
 			return &Node{a[index]}, true
 		}
 	}
@@ -200,6 +251,20 @@ func (j *Node) CheckGet(branch ...interface{}) (*Node, bool) {
 	return jin, true
 }
 
+// GetAny returns the value of the first of the given keys that is present in a map,
+// or NilNode if none of the keys are present. Useful for fields that have
+// different names in different versions of an API.
+//
+//	id := js.GetAny("userId", "user_id", "uid")
+func (j *Node) GetAny(keys ...string) *Node {
+	for _, key := range keys {
+		if n, ok := j.GetKey(key); ok {
+			return n
+		}
+	}
+	return NilNode
+}
+
 // CheckNodeMap returns a copy of a Node map, but with values as Nodes
 func (j *Node) CheckNodeMap() (NodeMap, bool) {
 	m, ok := j.CheckMap()
@@ -226,6 +291,203 @@ func (j *Node) CheckNodeList() ([]*Node, bool) {
 	return ja, true
 }
 
+// Range calls fn for each element in a list, in order, until fn returns false.
+// The same *Node is reused for each call, to avoid allocations, so it must
+// not be kept after fn returns. Nothing happens if the node is not a list.
+func (j *Node) Range(fn func(index int, n *Node) bool) {
+	a, ok := j.CheckList()
+	if !ok {
+		return
+	}
+	n := &Node{}
+	for i, val := range a {
+		n.data = val
+		if !fn(i, n) {
+			return
+		}
+	}
+}
+
+// RangeMap calls fn for each key and value in a map, until fn returns false.
+// The same *Node is reused for each call, to avoid allocations, so it must
+// not be kept after fn returns. Nothing happens if the node is not a map.
+func (j *Node) RangeMap(fn func(key string, n *Node) bool) {
+	m, ok := j.CheckMap()
+	if !ok {
+		return
+	}
+	n := &Node{}
+	for key, val := range m {
+		n.data = val
+		if !fn(key, n) {
+			return
+		}
+	}
+}
+
+// RangeOrdered is like RangeMap, but the keys are visited in sorted order,
+// so that the iteration order is the same every time.
+func (j *Node) RangeOrdered(fn func(key string, n *Node) bool) {
+	m, ok := j.CheckMap()
+	if !ok {
+		return
+	}
+	n := &Node{}
+	for _, key := range j.Keys() {
+		n.data = m[key]
+		if !fn(key, n) {
+			return
+		}
+	}
+}
+
+// Head returns a new list node with at most the first n elements of a list.
+// Returns NilNode if the node is not a list.
+func (j *Node) Head(n int) *Node {
+	a, ok := j.CheckList()
+	if !ok {
+		return NilNode
+	}
+	if n < 0 {
+		n = 0
+	}
+	if n > len(a) {
+		n = len(a)
+	}
+	l := make([]interface{}, n)
+	copy(l, a[:n])
+	return &Node{l}
+}
+
+// Tail returns a new list node with at most the last n elements of a list.
+// Returns NilNode if the node is not a list.
+func (j *Node) Tail(n int) *Node {
+	a, ok := j.CheckList()
+	if !ok {
+		return NilNode
+	}
+	if n < 0 {
+		n = 0
+	}
+	if n > len(a) {
+		n = len(a)
+	}
+	l := make([]interface{}, n)
+	copy(l, a[len(a)-n:])
+	return &Node{l}
+}
+
+// Window returns a new list node with the elements [offset, offset+limit) of a list,
+// clamped to the bounds of the list. Useful for pagination.
+// An offset past the end of the list gives an empty list.
+// Returns NilNode if the node is not a list.
+func (j *Node) Window(offset, limit int) *Node {
+	a, ok := j.CheckList()
+	if !ok {
+		return NilNode
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > len(a) {
+		offset = len(a)
+	}
+	if limit < 0 {
+		limit = 0
+	}
+	end := offset + limit
+	if end > len(a) || end < offset {
+		end = len(a)
+	}
+	l := make([]interface{}, end-offset)
+	copy(l, a[offset:end])
+	return &Node{l}
+}
+
+// Chunk splits a list into new list nodes with at most size elements each.
+// Only the last chunk may have fewer than size elements.
+// Returns nil if the node is not a list, or if size is less than 1.
+func (j *Node) Chunk(size int) NodeList {
+	a, ok := j.CheckList()
+	if !ok || size < 1 {
+		return nil
+	}
+	chunks := make(NodeList, 0, (len(a)+size-1)/size)
+	for start := 0; start < len(a); start += size {
+		end := start + size
+		if end > len(a) {
+			end = len(a)
+		}
+		l := make([]interface{}, end-start)
+		copy(l, a[start:end])
+		chunks = append(chunks, &Node{l})
+	}
+	return chunks
+}
+
+// Unwrap returns the only element of a list with a single element,
+// like "x" for ["x"]. Other nodes are returned as they are.
+// Useful for APIs that return either a value or a list with one value.
+func (j *Node) Unwrap() *Node {
+	if l, ok := j.CheckList(); ok && len(l) == 1 {
+		return &Node{l[0]}
+	}
+	return j
+}
+
+// UnwrapKey returns the value of the given key, if the node is a map where the given
+// key is the only key, like {"data": {...}}. Other nodes are returned as they are.
+// Useful for removing envelopes from API responses.
+func (j *Node) UnwrapKey(key string) *Node {
+	if m, ok := j.CheckMap(); ok && len(m) == 1 {
+		if val, ok := m[key]; ok {
+			return &Node{val}
+		}
+	}
+	return j
+}
+
+// AsList returns a list node, where a node that is not a list, like a string
+// or a map, is wrapped in a list with a single element. Lists are returned as
+// they are, and NilNode gives an empty list. This is the opposite of Unwrap.
+func (j *Node) AsList() *Node {
+	if _, ok := j.CheckList(); ok {
+		return j
+	}
+	if j == NilNode {
+		return &Node{[]interface{}{}}
+	}
+	return &Node{[]interface{}{j.data}}
+}
+
+// Keys returns the sorted keys of a map, or nil if the node is not a map
+func (j *Node) Keys() []string {
+	m, ok := j.CheckMap()
+	if !ok {
+		return nil
+	}
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Pairs returns the keys and values of a map, sorted by key,
+// or nil if the node is not a map
+func (j *Node) Pairs() []Pair {
+	m, ok := j.CheckMap()
+	if !ok {
+		return nil
+	}
+	pairs := make([]Pair, 0, len(m))
+	for _, key := range j.Keys() {
+		pairs = append(pairs, Pair{key, &Node{m[key]}})
+	}
+	return pairs
+}
+
 // CheckMap type asserts to `map`
 func (j *Node) CheckMap() (map[string]interface{}, bool) {
 	if m, ok := (j.data).(map[string]interface{}); ok {
@@ -262,12 +524,8 @@ func (j *Node) CheckString() (string, bool) {
 func (j *Node) NodeList(args ...NodeList) NodeList {
 	var def NodeList
 
-	switch len(args) {
-	case 0:
-	case 1:
+	if len(args) > 0 {
 		def = args[0]
-	default:
-		log.Panicf("NodeList() received too many arguments %d", len(args))
 	}
 
 	if a, ok := j.CheckNodeList(); ok {
@@ -281,12 +539,8 @@ func (j *Node) NodeList(args ...NodeList) NodeList {
 func (j *Node) NodeMap(args ...NodeMap) NodeMap {
 	var def NodeMap
 
-	switch len(args) {
-	case 0:
-	case 1:
+	if len(args) > 0 {
 		def = args[0]
-	default:
-		log.Panicf("NodeMap() received too many arguments %d", len(args))
 	}
 
 	if a, ok := j.CheckNodeMap(); ok {
@@ -306,12 +560,8 @@ func (j *Node) NodeMap(args ...NodeMap) NodeMap {
 func (j *Node) List(args ...[]interface{}) []interface{} {
 	var def []interface{}
 
-	switch len(args) {
-	case 0:
-	case 1:
+	if len(args) > 0 {
 		def = args[0]
-	default:
-		log.Panicf("List() received too many arguments %d", len(args))
 	}
 
 	if a, ok := j.CheckList(); ok {
@@ -331,12 +581,8 @@ func (j *Node) List(args ...[]interface{}) []interface{} {
 func (j *Node) Map(args ...map[string]interface{}) map[string]interface{} {
 	var def map[string]interface{}
 
-	switch len(args) {
-	case 0:
-	case 1:
+	if len(args) > 0 {
 		def = args[0]
-	default:
-		log.Panicf("Map() received too many arguments %d", len(args))
 	}
 
 	a, ok := j.CheckMap()
@@ -355,12 +601,8 @@ func (j *Node) Map(args ...map[string]interface{}) map[string]interface{} {
 func (j *Node) String(args ...string) string {
 	var def string
 
-	switch len(args) {
-	case 0:
-	case 1:
+	if len(args) > 0 {
 		def = args[0]
-	default:
-		log.Panicf("String() received too many arguments %d", len(args))
 	}
 
 	s, ok := j.CheckString()
@@ -379,12 +621,8 @@ func (j *Node) String(args ...string) string {
 func (j *Node) Int(args ...int) int {
 	var def int
 
-	switch len(args) {
-	case 0:
-	case 1:
+	if len(args) > 0 {
 		def = args[0]
-	default:
-		log.Panicf("Int() received too many arguments %d", len(args))
 	}
 
 	i, ok := j.CheckInt()
@@ -403,12 +641,8 @@ func (j *Node) Int(args ...int) int {
 func (j *Node) Float64(args ...float64) float64 {
 	var def float64
 
-	switch len(args) {
-	case 0:
-	case 1:
+	if len(args) > 0 {
 		def = args[0]
-	default:
-		log.Panicf("Float64() received too many arguments %d", len(args))
 	}
 
 	f, ok := j.CheckFloat64()
@@ -427,12 +661,8 @@ func (j *Node) Float64(args ...float64) float64 {
 func (j *Node) Bool(args ...bool) bool {
 	var def bool
 
-	switch len(args) {
-	case 0:
-	case 1:
+	if len(args) > 0 {
 		def = args[0]
-	default:
-		log.Panicf("Bool() received too many arguments %d", len(args))
 	}
 
 	b, ok := j.CheckBool()
@@ -443,6 +673,96 @@ func (j *Node) Bool(args ...bool) bool {
 	return def
 }
 
+// StringDefault is like String, but also returns true if the value was used,
+// or false if the given default value was returned instead
+func (j *Node) StringDefault(def string) (string, bool) {
+	if s, ok := j.CheckString(); ok {
+		return s, true
+	}
+	return def, false
+}
+
+// IntDefault is like Int, but also returns true if the value was used,
+// or false if the given default value was returned instead
+func (j *Node) IntDefault(def int) (int, bool) {
+	if i, ok := j.CheckInt(); ok {
+		return i, true
+	}
+	return def, false
+}
+
+// Float64Default is like Float64, but also returns true if the value was used,
+// or false if the given default value was returned instead
+func (j *Node) Float64Default(def float64) (float64, bool) {
+	if f, ok := j.CheckFloat64(); ok {
+		return f, true
+	}
+	return def, false
+}
+
+// BoolDefault is like Bool, but also returns true if the value was used,
+// or false if the given default value was returned instead
+func (j *Node) BoolDefault(def bool) (bool, bool) {
+	if b, ok := j.CheckBool(); ok {
+		return b, true
+	}
+	return def, false
+}
+
+// TryString is like String, but returns an error instead of a default value
+// if the node is not a string
+func (j *Node) TryString() (string, error) {
+	if s, ok := j.CheckString(); ok {
+		return s, nil
+	}
+	return "", errors.New("Not a string: " + j.Info())
+}
+
+// TryInt is like Int, but returns an error instead of a default value
+// if the node is not a number
+func (j *Node) TryInt() (int, error) {
+	if i, ok := j.CheckInt(); ok {
+		return i, nil
+	}
+	return 0, errors.New("Not an int: " + j.Info())
+}
+
+// TryInt64 is like Int64, but returns an error instead of a default value
+// if the node is not a number
+func (j *Node) TryInt64() (int64, error) {
+	if i, ok := j.CheckInt64(); ok {
+		return i, nil
+	}
+	return 0, errors.New("Not an int64: " + j.Info())
+}
+
+// TryUint64 is like Uint64, but returns an error instead of a default value
+// if the node is not a number
+func (j *Node) TryUint64() (uint64, error) {
+	if i, ok := j.CheckUint64(); ok {
+		return i, nil
+	}
+	return 0, errors.New("Not an uint64: " + j.Info())
+}
+
+// TryFloat64 is like Float64, but returns an error instead of a default value
+// if the node is not a number
+func (j *Node) TryFloat64() (float64, error) {
+	if f, ok := j.CheckFloat64(); ok {
+		return f, nil
+	}
+	return 0, errors.New("Not a float64: " + j.Info())
+}
+
+// TryBool is like Bool, but returns an error instead of a default value
+// if the node is not a boolean
+func (j *Node) TryBool() (bool, error) {
+	if b, ok := j.CheckBool(); ok {
+		return b, nil
+	}
+	return false, errors.New("Not a bool: " + j.Info())
+}
+
 // Int64 guarantees the return of an `int64` (with optional default)
 //
 // useful when you explicitly want an `int64` in a single value return context:
@@ -451,12 +771,8 @@ func (j *Node) Bool(args ...bool) bool {
 func (j *Node) Int64(args ...int64) int64 {
 	var def int64
 
-	switch len(args) {
-	case 0:
-	case 1:
+	if len(args) > 0 {
 		def = args[0]
-	default:
-		log.Panicf("Int64() received too many arguments %d", len(args))
 	}
 
 	i, ok := j.CheckInt64()
@@ -475,12 +791,8 @@ func (j *Node) Int64(args ...int64) int64 {
 func (j *Node) Uint64(args ...uint64) uint64 {
 	var def uint64
 
-	switch len(args) {
-	case 0:
-	case 1:
+	if len(args) > 0 {
 		def = args[0]
-	default:
-		log.Panicf("Uint64() received too many arguments %d", len(args))
 	}
 
 	i, ok := j.CheckUint64()
@@ -491,19 +803,242 @@ func (j *Node) Uint64(args ...uint64) uint64 {
 	return def
 }
 
-// NewFromReader returns a *Node by decoding from an io.Reader
+// Number guarantees the return of a `json.Number` (with optional default)
+//
+// useful when the exact representation of a number must be preserved:
+//
+//	myFunc(js.Get("param1").Number(), js.Get("optional_param").Number("5150"))
+func (j *Node) Number(args ...json.Number) json.Number {
+	var def json.Number
+
+	if len(args) > 0 {
+		def = args[0]
+	}
+
+	n, ok := j.CheckNumber()
+	if ok {
+		return n
+	}
+
+	return def
+}
+
+// BigInt guarantees the return of a `*big.Int` (with optional default)
+//
+// useful for integers that are too large for an int64 or uint64:
+//
+//	myFunc(js.Get("param1").BigInt(), js.Get("optional_param").BigInt(big.NewInt(5150)))
+func (j *Node) BigInt(args ...*big.Int) *big.Int {
+	var def *big.Int
+
+	if len(args) > 0 {
+		def = args[0]
+	}
+
+	i, ok := j.CheckBigInt()
+	if ok {
+		return i
+	}
+
+	return def
+}
+
+// BigFloat guarantees the return of a `*big.Float` (with optional default)
+//
+// useful for decimals that can not be represented exactly by a float64:
+//
+//	myFunc(js.Get("param1").BigFloat(), js.Get("optional_param").BigFloat(big.NewFloat(5.150)))
+func (j *Node) BigFloat(args ...*big.Float) *big.Float {
+	var def *big.Float
+
+	if len(args) > 0 {
+		def = args[0]
+	}
+
+	f, ok := j.CheckBigFloat()
+	if ok {
+		return f
+	}
+
+	return def
+}
+
+// NewFromReader returns a *Node by decoding from an io.Reader.
+// A leading UTF-8 byte order mark is ignored.
 func NewFromReader(r io.Reader) (*Node, error) {
 	j := new(Node)
-	dec := json.NewDecoder(r)
+	dec := json.NewDecoder(skipBOM(r))
 	err := dec.Decode(&j.data)
 	return j, err
 }
 
+// NewNumber is like New, but numbers are decoded as json.Number instead of float64.
+// This preserves the exact representation of all numbers, including integers that
+// are too large to be represented exactly by a float64, at the cost of having to
+// convert the numbers with Int64, Float64 etc. before doing arithmetic with them.
+func NewNumber(body []byte) (*Node, error) {
+	body = bytes.TrimPrefix(body, bomUTF8)
+	if len(body) == 0 {
+		// Use an empty list if no data has been provided
+		body = []byte("[]")
+	}
+	return NewFromReaderNumber(bytes.NewReader(body))
+}
+
+// Minify removes all insignificant whitespace from the given JSON data, without
+// decoding it, which is faster than New followed by JSON. Numbers, strings and the
+// order of map keys are kept exactly as they are. A leading UTF-8 byte order mark is removed.
+func Minify(body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, bytes.TrimPrefix(body, bomUTF8)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// NewFromReaderNumber is like NewFromReader, but numbers are decoded as json.Number instead of float64.
+// Like for New, the data must contain a single JSON document, and anything after it gives an error.
+func NewFromReaderNumber(r io.Reader) (*Node, error) {
+	j := new(Node)
+	dec := json.NewDecoder(skipBOM(r))
+	dec.UseNumber()
+	if err := dec.Decode(&j.data); err != nil {
+		return nil, err
+	}
+	// Check that there is nothing but whitespace after the JSON document
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("invalid data after the JSON document")
+	}
+	return j, nil
+}
+
+// NewRawAt is like New, but the values at the given JSON paths are kept as the
+// original JSON, including formatting and key order, instead of being decoded.
+// The original JSON can be retrieved with Raw. Useful for passing on or verifying
+// the signature of embedded payloads. JSON paths that are not found are skipped.
+func NewRawAt(body []byte, keepPaths ...string) (*Node, error) {
+	body = bytes.TrimPrefix(body, bomUTF8)
+	j, err := New(body)
+	if err != nil {
+		return nil, err
+	}
+	for _, JSONpath := range keepPaths {
+		cp, err := compileSinglePath(JSONpath)
+		if err != nil {
+			return nil, err
+		}
+		raw, ok := rawAt(body, cp.segments)
+		if !ok {
+			continue
+		}
+		if len(cp.segments) == 0 {
+			j.data = raw
+			continue
+		}
+		parentPath := &CompiledPath{segments: cp.segments[:len(cp.segments)-1]}
+		parent, _ := j.getNodes(parentPath)
+		parent.setSegment(cp.segments[len(cp.segments)-1], raw)
+	}
+	return j, nil
+}
+
+// rawAt returns the original JSON for the value that the given path segments lead to
+func rawAt(body []byte, segments []pathSegment) (json.RawMessage, bool) {
+	raw := json.RawMessage(bytes.TrimSpace(body))
+	for _, seg := range segments {
+		switch seg.kind {
+		case keySegment:
+			var m map[string]json.RawMessage
+			if json.Unmarshal(raw, &m) != nil {
+				return nil, false
+			}
+			val, ok := m[seg.key]
+			if !ok {
+				return nil, false
+			}
+			raw = val
+		case indexSegment:
+			var l []json.RawMessage
+			if json.Unmarshal(raw, &l) != nil {
+				return nil, false
+			}
+			index := seg.index
+			if index < 0 {
+				index += len(l)
+			}
+			if index < 0 || index >= len(l) {
+				return nil, false
+			}
+			raw = l[index]
+		default:
+			return nil, false
+		}
+	}
+	return raw, true
+}
+
+// Raw returns the original JSON for a node that was kept as it was by NewRawAt.
+// Returns false if the node was decoded as usual.
+func (j *Node) Raw() ([]byte, bool) {
+	raw, ok := j.data.(json.RawMessage)
+	return raw, ok
+}
+
 // UnmarshalJSON implements the json.Unmarshaler interface
 func (j *Node) UnmarshalJSON(p []byte) error {
 	return json.Unmarshal(p, &j.data)
 }
 
+// CheckNumber returns the number as a json.Number, without any loss of precision
+// if the document was decoded with NewNumber
+func (j *Node) CheckNumber() (json.Number, bool) {
+	switch j.data.(type) {
+	case json.Number:
+		return j.data.(json.Number), true
+	case float32, float64, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return json.Number(scalarString(j.data)), true
+	}
+	return "", false
+}
+
+// CheckBigInt converts an integer into a *big.Int, without any loss of precision
+// if the document was decoded with NewNumber
+func (j *Node) CheckBigInt() (*big.Int, bool) {
+	n, ok := j.CheckNumber()
+	if !ok {
+		return nil, false
+	}
+	if i, ok := new(big.Int).SetString(n.String(), 10); ok {
+		return i, true
+	}
+	// The number may be written with a fraction or an exponent, like 1.0 or 1e40
+	r, ok := new(big.Rat).SetString(n.String())
+	if !ok || !r.IsInt() {
+		return nil, false
+	}
+	return r.Num(), true
+}
+
+// CheckBigFloat converts a number into a *big.Float, with a precision that is
+// high enough to represent all the digits of the number if the document was
+// decoded with NewNumber. The precision can be changed with SetPrec.
+func (j *Node) CheckBigFloat() (*big.Float, bool) {
+	n, ok := j.CheckNumber()
+	if !ok {
+		return nil, false
+	}
+	// Each decimal digit needs a bit less than 4 bits
+	prec := uint(len(n)) * 4
+	if prec < 64 {
+		prec = 64
+	}
+	f, _, err := big.ParseFloat(n.String(), 10, prec, big.ToNearestEven)
+	if err != nil {
+		return nil, false
+	}
+	return f, true
+}
+
 // CheckFloat64 coerces into a float64
 func (j *Node) CheckFloat64() (float64, bool) {
 	switch j.data.(type) {
@@ -513,6 +1048,9 @@ func (j *Node) CheckFloat64() (float64, bool) {
 		return float64(reflect.ValueOf(j.data).Int()), true
 	case uint, uint8, uint16, uint32, uint64:
 		return float64(reflect.ValueOf(j.data).Uint()), true
+	case json.Number:
+		f, err := j.data.(json.Number).Float64()
+		return f, err == nil
 	}
 	return 0, false
 }
@@ -526,6 +1064,9 @@ func (j *Node) CheckInt() (int, bool) {
 		return int(reflect.ValueOf(j.data).Int()), true
 	case uint, uint8, uint16, uint32, uint64:
 		return int(reflect.ValueOf(j.data).Uint()), true
+	case json.Number:
+		i, ok := j.CheckInt64()
+		return int(i), ok
 	}
 	return 0, false
 }
@@ -539,6 +1080,12 @@ func (j *Node) CheckInt64() (int64, bool) {
 		return reflect.ValueOf(j.data).Int(), true
 	case uint, uint8, uint16, uint32, uint64:
 		return int64(reflect.ValueOf(j.data).Uint()), true
+	case json.Number:
+		if i, err := j.data.(json.Number).Int64(); err == nil {
+			return i, true
+		}
+		f, err := j.data.(json.Number).Float64()
+		return int64(f), err == nil
 	}
 	return 0, false
 }
@@ -552,66 +1099,25 @@ func (j *Node) CheckUint64() (uint64, bool) {
 		return uint64(reflect.ValueOf(j.data).Int()), true
 	case uint, uint8, uint16, uint32, uint64:
 		return reflect.ValueOf(j.data).Uint(), true
+	case json.Number:
+		if u, err := strconv.ParseUint(j.data.(json.Number).String(), 10, 64); err == nil {
+			return u, true
+		}
+		f, err := j.data.(json.Number).Float64()
+		return uint64(f), err == nil
 	}
 	return 0, false
 }
 
-// GetNodes will find the JSON node (and parent node) that corresponds to the given JSON path
+// GetNodes will find the JSON node (and parent node) that corresponds to the given JSON path.
+// Parsed JSON paths are cached, so that using the same JSON path repeatedly is fast.
+// Returns ErrMultiplePath if the JSON path contains wildcards.
 func (j *Node) GetNodes(JSONpath string) (*Node, *Node, error) {
-	parent := j
-	if JSONpath == "x" || JSONpath == "" {
-		// If the root node is a map or list with one element or less, use that as the node
-		if m, ok := j.CheckNodeMap(); ok && len(m) <= 1 {
-			return parent, NilNode, nil
-		} else if l, ok := j.CheckNodeList(); ok && len(l) <= 1 {
-			return parent, NilNode, nil
-		}
-		// We may have encountered a list with more than one item, for example
-		return parent, NilNode, nil
-	}
-	// JSON path starting with x[ is a special case.
-	if strings.HasPrefix(JSONpath, "x[") {
-		// Add a "." between "x" and "[".
-		JSONpath = "x." + JSONpath[1:]
-	}
-	// The "current node" starts out with being the root node
-	n := j
-	if strings.Contains(JSONpath, ".") {
-		for i, part := range strings.Split(JSONpath, ".") {
-			if i == 0 && (part == "" || part == "x") {
-				// If the current node is a map or list with one element or less, use that as the next node
-				if m, ok := n.CheckNodeMap(); ok && len(m) <= 1 {
-					n = parent
-				} else if l, ok := n.CheckNodeList(); ok && len(l) <= 1 {
-					n = parent
-				}
-			} else if strings.Contains(part, "[") {
-				fields := strings.SplitN(part, "[", 2)
-				name := fields[0]
-				secondpart := fields[1]
-				fields = strings.SplitN(secondpart, "]", 2)
-				stringIndex := fields[0]
-				index, err := strconv.Atoi(stringIndex)
-				if err != nil {
-					return NilNode, NilNode, errors.New("Invalid index: " + stringIndex)
-				}
-				parent = n
-				if name == "" {
-					n = n.Get(index)
-				} else {
-					parent = n.Get(name)
-					n = parent.Get(index)
-				}
-			} else {
-				parent = n
-				n = n.Get(part)
-			}
-		}
-	} else {
-		parent = n
-		part := JSONpath
-		n = n.Get(part)
+	cp, err := compileSinglePath(JSONpath)
+	if err != nil {
+		return NilNode, NilNode, err
 	}
+	n, parent := j.getNodes(cp)
 	return n, parent, nil
 }
 
@@ -624,6 +1130,288 @@ func (j *Node) GetNode(JSONpath string) *Node {
 	return node
 }
 
+// RawAt returns the compact JSON for the node at the given JSON path.
+// The node is encoded again, so the formatting and key order of the
+// original document is not preserved.
+// Returns ErrKeyNotFound if there is no node at the given path.
+func (j *Node) RawAt(JSONpath string) ([]byte, error) {
+	cp, err := compileSinglePath(JSONpath)
+	if err != nil {
+		return []byte{}, err
+	}
+	n, err := j.GetCompiled(cp)
+	if err != nil {
+		return []byte{}, err
+	}
+	return n.JSON()
+}
+
+// SetNode sets the value at the given JSON path. The JSON path must lead to
+// a key in an existing map or an index in an existing list.
+// The root node can be replaced by using "." (or "x") as the JSON path.
+func (j *Node) SetNode(JSONpath string, val interface{}) error {
+	cp, err := compileSinglePath(JSONpath)
+	if err != nil {
+		return err
+	}
+	if len(cp.segments) == 0 {
+		if j == NilNode {
+			return ErrNilNode
+		}
+		j.data = val
+		return nil
+	}
+	_, parent := j.getNodes(cp)
+	return parent.setSegment(cp.segments[len(cp.segments)-1], val)
+}
+
+// setSegment sets the value for the given map key or list index segment
+func (j *Node) setSegment(seg pathSegment, val interface{}) error {
+	if seg.kind == indexSegment {
+		l, ok := j.CheckList()
+		if !ok {
+			return errors.New("Can only set an index in a list. Not a list: " + j.Info())
+		}
+		index := seg.index
+		if index < 0 {
+			index += len(l)
+		}
+		if index < 0 || index >= len(l) {
+			return fmt.Errorf("Index out of range: %d", seg.index)
+		}
+		l[index] = val
+		return nil
+	}
+	m, ok := j.CheckMap()
+	if !ok {
+		return errors.New("Can only set a key in a map. Not a map: " + j.Info())
+	}
+	m[seg.key] = val
+	return nil
+}
+
+// GetOrCreate returns the map that the given JSON path leads to, creating
+// it and any missing maps along the way. Returns an error if the JSON path
+// leads through or to a value that is not a map, or to a missing list index.
+//
+//	section, err := document.GetOrCreate(".database")
+//	section.Set("host", "localhost")
+func (j *Node) GetOrCreate(JSONpath string) (*Node, error) {
+	cp, err := compileSinglePath(JSONpath)
+	if err != nil {
+		return NilNode, err
+	}
+	n, err := j.getOrCreate(cp.segments)
+	if err != nil {
+		return NilNode, err
+	}
+	if _, ok := n.CheckMap(); !ok {
+		return NilNode, errors.New("Not a map: " + n.Info())
+	}
+	return n, nil
+}
+
+// checkCreate checks that the given path segments can be followed by getOrCreate, without
+// creating anything, so that the document is not modified if a later segment fails
+func (j *Node) checkCreate(segments []pathSegment) error {
+	n, created := j, false
+	for _, seg := range segments {
+		if seg.kind == indexSegment {
+			next, ok := n.GetIndex(seg.index)
+			if created || !ok {
+				return fmt.Errorf("Index out of range: %d", seg.index)
+			}
+			n = next
+			continue
+		}
+		if created {
+			continue
+		}
+		m, ok := n.CheckMap()
+		if !ok {
+			return errors.New("Not a map: " + n.Info())
+		}
+		val, ok := m[seg.key]
+		created = !ok
+		n = &Node{val}
+	}
+	return nil
+}
+
+// getOrCreate follows the given path segments, creating missing maps along the way.
+// Nothing is created if one of the path segments can not be followed.
+func (j *Node) getOrCreate(segments []pathSegment) (*Node, error) {
+	if err := j.checkCreate(segments); err != nil {
+		return NilNode, err
+	}
+	n := j
+	for _, seg := range segments {
+		if seg.kind == indexSegment {
+			next, ok := n.GetIndex(seg.index)
+			if !ok {
+				return NilNode, fmt.Errorf("Index out of range: %d", seg.index)
+			}
+			n = next
+			continue
+		}
+		m, ok := n.CheckMap()
+		if !ok {
+			return NilNode, errors.New("Not a map: " + n.Info())
+		}
+		if _, ok := m[seg.key]; !ok {
+			m[seg.key] = make(map[string]interface{})
+		}
+		n = &Node{m[seg.key]}
+	}
+	return n, nil
+}
+
+// SetRawJSON parses the given JSON data and sets it at the given JSON path,
+// replacing any existing value. Missing maps along the way are created.
+func (j *Node) SetRawJSON(JSONpath string, JSONdata []byte) error {
+	cp, err := compileSinglePath(JSONpath)
+	if err != nil {
+		return err
+	}
+	if len(bytes.TrimSpace(JSONdata)) == 0 {
+		return errors.New("no JSON data")
+	}
+	newNode, err := New(JSONdata)
+	if err != nil {
+		return err
+	}
+	if len(cp.segments) == 0 {
+		if j == NilNode {
+			return ErrNilNode
+		}
+		j.data = newNode.data
+		return nil
+	}
+	// Check the whole path, including the last segment, before creating anything
+	if err := j.checkCreate(cp.segments); err != nil {
+		return err
+	}
+	parent, err := j.getOrCreate(cp.segments[:len(cp.segments)-1])
+	if err != nil {
+		return err
+	}
+	return parent.setSegment(cp.segments[len(cp.segments)-1], newNode.data)
+}
+
+// ParseEmbedded parses the JSON document that is stored as a string
+// at the given JSON path, and replaces the string with the parsed data.
+func (j *Node) ParseEmbedded(JSONpath string) error {
+	cp, err := compileSinglePath(JSONpath)
+	if err != nil {
+		return err
+	}
+	node, parent := j.getNodes(cp)
+	s, ok := node.CheckString()
+	if !ok {
+		return errors.New("Can only parse embedded JSON in a string. Not a string: " + node.Info())
+	}
+	newNode := new(Node)
+	if err := newNode.UnmarshalJSON([]byte(s)); err != nil {
+		return err
+	}
+	if len(cp.segments) == 0 {
+		if j == NilNode {
+			return ErrNilNode
+		}
+		j.data = newNode.data
+		return nil
+	}
+	return parent.setSegment(cp.segments[len(cp.segments)-1], newNode.data)
+}
+
+// StringifyNode replaces the node at the given JSON path with a string
+// containing the node as JSON. This is the opposite of ParseEmbedded.
+func (j *Node) StringifyNode(JSONpath string) error {
+	cp, err := compileSinglePath(JSONpath)
+	if err != nil {
+		return err
+	}
+	node, parent := j.getNodes(cp)
+	if node == NilNode {
+		return ErrKeyNotFound
+	}
+	data, err := node.JSON()
+	if err != nil {
+		return err
+	}
+	if len(cp.segments) == 0 {
+		j.data = string(data)
+		return nil
+	}
+	return parent.setSegment(cp.segments[len(cp.segments)-1], string(data))
+}
+
+// ErrNoParent is for when asking for the parent of the root node
+var ErrNoParent = errors.New("the root node has no parent")
+
+// Parent returns the map or list that contains the node at the given JSON path.
+// Returns ErrNoParent for the root node, and ErrKeyNotFound if there is no node
+// at the given JSON path.
+func (j *Node) Parent(JSONpath string) (*Node, error) {
+	cp, err := compileSinglePath(JSONpath)
+	if err != nil {
+		return NilNode, err
+	}
+	if len(cp.segments) == 0 {
+		return NilNode, ErrNoParent
+	}
+	node, parent := j.getNodes(cp)
+	if node == NilNode {
+		return NilNode, ErrKeyNotFound
+	}
+	return parent, nil
+}
+
+// GetWithParent returns the node at the given JSON path, together with the map
+// or list that contains it. Unlike GetNodes, ErrKeyNotFound is returned if there
+// is no node at the given JSON path. For the root node, the parent is NilNode.
+func (j *Node) GetWithParent(JSONpath string) (node *Node, parent *Node, err error) {
+	cp, err := compileSinglePath(JSONpath)
+	if err != nil {
+		return NilNode, NilNode, err
+	}
+	node, parent = j.getNodes(cp)
+	if node == NilNode {
+		return NilNode, NilNode, ErrKeyNotFound
+	}
+	return node, parent, nil
+}
+
+// GetMap returns the map at the given JSON path, with the values as nodes.
+// Returns ErrKeyNotFound if there is no node at the given JSON path,
+// or an error if the node is not a map.
+func (j *Node) GetMap(JSONpath string) (NodeMap, error) {
+	node, _, err := j.GetWithParent(JSONpath)
+	if err != nil {
+		return nil, err
+	}
+	m, ok := node.CheckNodeMap()
+	if !ok {
+		return nil, errors.New("Not a map: " + node.Info())
+	}
+	return m, nil
+}
+
+// GetList returns the list at the given JSON path, with the elements as nodes.
+// Returns ErrKeyNotFound if there is no node at the given JSON path,
+// or an error if the node is not a list.
+func (j *Node) GetList(JSONpath string) (NodeList, error) {
+	node, _, err := j.GetWithParent(JSONpath)
+	if err != nil {
+		return nil, err
+	}
+	l, ok := node.CheckNodeList()
+	if !ok {
+		return nil, errors.New("Not a list: " + node.Info())
+	}
+	return l, nil
+}
+
 // AddJSON adds JSON data to a list. The JSON path must refer to a list.
 func (j *Node) AddJSON(JSONpath string, JSONdata []byte) error {
 	node := j.GetNode(JSONpath)
@@ -665,6 +1453,155 @@ func (j *Node) DelKey(JSONpath string) error {
 	return nil
 }
 
+// SanitizeUTF8 replaces invalid UTF-8 in all strings, including map keys,
+// with the Unicode replacement character. Returns the number of strings that were changed.
+// Map keys are handled in sorted order, so if a sanitized key is the same as another key,
+// the value for the last of the invalid keys in sorted order is used.
+func (j *Node) SanitizeUTF8() int {
+	var count int
+	j.data = sanitizeUTF8(j.data, &count)
+	return count
+}
+
+// sanitizeUTF8 returns the given value with invalid UTF-8 replaced in all strings,
+// and increases count for each string that is changed
+func sanitizeUTF8(v interface{}, count *int) interface{} {
+	switch v := v.(type) {
+	case string:
+		if !utf8.ValidString(v) {
+			*count++
+			return strings.ToValidUTF8(v, string(utf8.RuneError))
+		}
+	case *Node:
+		v.data = sanitizeUTF8(v.data, count)
+	case map[string]interface{}:
+		keys := (&Node{v}).Keys()
+		for _, key := range keys {
+			v[key] = sanitizeUTF8(v[key], count)
+		}
+		for _, key := range keys {
+			if utf8.ValidString(key) {
+				continue
+			}
+			*count++
+			val := v[key]
+			delete(v, key)
+			v[strings.ToValidUTF8(key, string(utf8.RuneError))] = val
+		}
+	case []interface{}:
+		for i, val := range v {
+			v[i] = sanitizeUTF8(val, count)
+		}
+	}
+	return v
+}
+
+// withPrecision returns a copy of the given value, where all numbers that are
+// not integers are formatted with the given number of significant digits
+func withPrecision(v interface{}, prec int) interface{} {
+	switch v := v.(type) {
+	case float32:
+		return withPrecision(float64(v), prec)
+	case float64:
+		if v == math.Trunc(v) || math.IsNaN(v) || math.IsInf(v, 0) {
+			return v
+		}
+		return json.Number(strconv.FormatFloat(v, 'g', prec, 64))
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return v
+		}
+		if f, err := v.Float64(); err == nil {
+			return withPrecision(f, prec)
+		}
+	case *Node:
+		return withPrecision(v.data, prec)
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			m[key] = withPrecision(val, prec)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, val := range v {
+			l[i] = withPrecision(val, prec)
+		}
+		return l
+	}
+	return v
+}
+
+// LeafPaths returns the JSON paths to all strings, numbers, booleans and nulls
+// in the document, like "x.people.names[0]". Map keys are visited in sorted order.
+func (j *Node) LeafPaths() []string {
+	var paths []string
+	walkLeafPaths("x", j.data, func(JSONpath string, _ interface{}) {
+		paths = append(paths, JSONpath)
+	})
+	return paths
+}
+
+// Depth returns the number of keys and indexes in the given JSON path, which is
+// how deeply nested the node at the JSON path is. The root node has depth 0.
+// Returns ErrKeyNotFound if there is no node at the given JSON path.
+func (j *Node) Depth(JSONpath string) (int, error) {
+	cp, err := compileSinglePath(JSONpath)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := j.GetCompiled(cp); err != nil {
+		return 0, err
+	}
+	return len(cp.segments), nil
+}
+
+// MaxDepth returns the depth of the most deeply nested value in the document,
+// where the values in the root map or list have depth 1
+func (j *Node) MaxDepth() int {
+	return maxDepth(j.data)
+}
+
+// maxDepth returns the depth of the most deeply nested value within v
+func maxDepth(v interface{}) int {
+	deepest := 0
+	switch v := v.(type) {
+	case *Node:
+		return maxDepth(v.data)
+	case map[string]interface{}:
+		for _, val := range v {
+			if d := 1 + maxDepth(val); d > deepest {
+				deepest = d
+			}
+		}
+	case []interface{}:
+		for _, val := range v {
+			if d := 1 + maxDepth(val); d > deepest {
+				deepest = d
+			}
+		}
+	}
+	return deepest
+}
+
+// walkLeafPaths calls fn with the JSON path and value of all leaves of the given value
+func walkLeafPaths(JSONpath string, v interface{}, fn func(JSONpath string, leaf interface{})) {
+	switch v := v.(type) {
+	case *Node:
+		walkLeafPaths(JSONpath, v.data, fn)
+	case map[string]interface{}:
+		for _, key := range (&Node{v}).Keys() {
+			walkLeafPaths(keyPath(JSONpath, key), v[key], fn)
+		}
+	case []interface{}:
+		for i, val := range v {
+			walkLeafPaths(indexPath(JSONpath, i), val, fn)
+		}
+	default:
+		fn(JSONpath, v)
+	}
+}
+
 // Info returns a description of the node
 func (j *Node) Info() string {
 	var buf bytes.Buffer
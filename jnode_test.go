@@ -3,7 +3,10 @@ package jpath
 import (
 	"bytes"
 	"encoding/json"
+	"math"
+	"math/big"
 	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/bmizerany/assert"
@@ -469,3 +472,820 @@ func TestDel(t *testing.T) {
 
 	assert.Equal(t, true, bytes.Equal(newJSON, correctJSON))
 }
+
+func TestRange(t *testing.T) {
+	js, err := New([]byte(`{"list": [1, 2, 3, 4], "map": {"a": 1, "b": 2, "c": 3}}`))
+	assert.Equal(t, nil, err)
+
+	sum := 0
+	js.Get("list").Range(func(i int, n *Node) bool {
+		assert.Equal(t, i+1, n.Int())
+		sum += n.Int()
+		return true
+	})
+	assert.Equal(t, 10, sum)
+
+	// Stop early
+	count := 0
+	js.Get("list").Range(func(i int, n *Node) bool {
+		count++
+		return i < 1
+	})
+	assert.Equal(t, 2, count)
+
+	sum = 0
+	js.Get("map").RangeMap(func(key string, n *Node) bool {
+		sum += n.Int()
+		return true
+	})
+	assert.Equal(t, 6, sum)
+
+	count = 0
+	js.Get("map").RangeMap(func(key string, n *Node) bool {
+		count++
+		return false
+	})
+	assert.Equal(t, 1, count)
+
+	// Not a list or a map
+	count = 0
+	js.Get("map").Range(func(i int, n *Node) bool {
+		count++
+		return true
+	})
+	js.Get("list").RangeMap(func(key string, n *Node) bool {
+		count++
+		return true
+	})
+	assert.Equal(t, 0, count)
+}
+
+func bigListNode() *Node {
+	l := make([]interface{}, 10000)
+	for i := range l {
+		l[i] = float64(i)
+	}
+	return &Node{l}
+}
+
+func BenchmarkRange(b *testing.B) {
+	js := bigListNode()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sum := 0.0
+		js.Range(func(index int, n *Node) bool {
+			sum += n.Float64()
+			return true
+		})
+	}
+}
+
+func BenchmarkNodeList(b *testing.B) {
+	js := bigListNode()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sum := 0.0
+		for _, n := range js.NodeList() {
+			sum += n.Float64()
+		}
+	}
+}
+
+func TestHeadTail(t *testing.T) {
+	js, err := New([]byte(`{"list": [1, 2, 3, 4, 5], "string": "abc"}`))
+	assert.Equal(t, nil, err)
+
+	list := js.Get("list")
+	assert.Equal(t, []interface{}{1.0, 2.0}, list.Head(2).List())
+	assert.Equal(t, []interface{}{4.0, 5.0}, list.Tail(2).List())
+
+	// Shorter than n
+	assert.Equal(t, 5, len(list.Head(10).List()))
+	assert.Equal(t, 5, len(list.Tail(10).List()))
+
+	// The original list is not modified
+	head := list.Head(2)
+	head.List()[0] = 42.0
+	assert.Equal(t, 1, list.Get(0).Int())
+
+	// Not a list
+	assert.Equal(t, NilNode, js.Get("string").Head(2))
+	assert.Equal(t, NilNode, js.Get("string").Tail(2))
+}
+
+func TestWindow(t *testing.T) {
+	js, err := New([]byte(`{"list": [1, 2, 3, 4, 5], "string": "abc"}`))
+	assert.Equal(t, nil, err)
+
+	list := js.Get("list")
+	assert.Equal(t, []interface{}{2.0, 3.0}, list.Window(1, 2).List())
+
+	// Offset past the end
+	assert.Equal(t, []interface{}{}, list.Window(10, 2).List())
+
+	// Limit exceeding the remaining elements
+	assert.Equal(t, []interface{}{4.0, 5.0}, list.Window(3, 10).List())
+
+	// Not a list
+	assert.Equal(t, NilNode, js.Get("string").Window(0, 1))
+}
+
+func TestPairs(t *testing.T) {
+	js, err := New([]byte(`{"c": 3, "a": 1, "b": 2, "e": 5, "d": 4}`))
+	assert.Equal(t, nil, err)
+
+	assert.Equal(t, []string{"a", "b", "c", "d", "e"}, js.Keys())
+
+	pairs := js.Pairs()
+	assert.Equal(t, 5, len(pairs))
+	for i, pair := range pairs {
+		assert.Equal(t, js.Keys()[i], pair.Key)
+		assert.Equal(t, i+1, pair.Value.Int())
+	}
+	assert.Equal(t, pairs, js.Pairs())
+
+	// Not a map
+	assert.Equal(t, 0, len(js.Get("a").Pairs()))
+}
+
+func TestCheckedJSON(t *testing.T) {
+	js, err := New([]byte(`{"a": {"b": [1, 2]}}`))
+	assert.Equal(t, nil, err)
+
+	data, err := js.CheckedJSON()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `{"a":{"b":[1,2]}}`, string(data))
+
+	js.Get("a").Set("c", make(chan int))
+	_, err = js.CheckedJSON()
+	assert.NotEqual(t, nil, err)
+	assert.Equal(t, true, strings.Contains(err.Error(), "x.a.c"))
+
+	js.Get("a").Set("c", []interface{}{1, math.NaN()})
+	_, err = js.CheckedJSON()
+	assert.NotEqual(t, nil, err)
+	assert.Equal(t, true, strings.Contains(err.Error(), "x.a.c[1]"))
+}
+
+func TestSetChecked(t *testing.T) {
+	js, err := New([]byte(`{}`))
+	assert.Equal(t, nil, err)
+
+	err = js.SetChecked("a", struct{ C chan int }{make(chan int)})
+	assert.NotEqual(t, nil, err)
+	assert.Equal(t, NilNode, js.Get("a"))
+
+	err = js.SetChecked("b", map[string]interface{}{"c": []interface{}{1, "2", nil, true}})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "2", js.GetNode(".b.c[1]").String())
+
+	err = js.Get("b").Get("c").SetChecked("d", 1)
+	assert.NotEqual(t, nil, err)
+
+	// The error is about the first invalid value in sorted key order, with a JSON path
+	for i := 0; i < 10; i++ {
+		err = js.SetChecked("e", map[string]interface{}{"z": math.NaN(), "y": math.Inf(1), "a": 1})
+		assert.Equal(t, "can not encode +Inf at x.e.y as JSON", err.Error())
+	}
+}
+
+func TestGetOrCreate(t *testing.T) {
+	js, err := New([]byte(`{"server": {"port": 8080}, "list": [{"a": 1}]}`))
+	assert.Equal(t, nil, err)
+
+	section, err := js.GetOrCreate(".database.primary")
+	assert.Equal(t, nil, err)
+	section.Set("host", "localhost")
+	assert.Equal(t, "localhost", js.GetNode(".database.primary.host").String())
+
+	section, err = js.GetOrCreate(".server")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 8080, section.Get("port").Int())
+
+	section, err = js.GetOrCreate(".list[0].b")
+	assert.Equal(t, nil, err)
+	section.Set("c", "d")
+	assert.Equal(t, "d", js.GetNode(".list[0].b.c").String())
+
+	_, err = js.GetOrCreate(".server.port")
+	assert.NotEqual(t, nil, err)
+	_, err = js.GetOrCreate(".server.port.number")
+	assert.NotEqual(t, nil, err)
+	_, err = js.GetOrCreate(".list[1]")
+	assert.NotEqual(t, nil, err)
+}
+
+func TestNewNumber(t *testing.T) {
+	data := []byte(`{"int": 9007199254740993, "float": 1.5}`)
+
+	js, err := New(data)
+	assert.Equal(t, nil, err)
+	_, ok := js.Get("float").Interface().(float64)
+	assert.Equal(t, true, ok)
+
+	js, err = NewNumber(data)
+	assert.Equal(t, nil, err)
+	_, ok = js.Get("float").Interface().(json.Number)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, 1.5, js.Get("float").Float64())
+	assert.Equal(t, int64(9007199254740993), js.Get("int").Int64())
+	assert.Equal(t, uint64(9007199254740993), js.Get("int").Uint64())
+	assert.Equal(t, 1, js.Get("float").Int())
+
+	newJSON, err := js.JSON()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `{"float":1.5,"int":9007199254740993}`, string(newJSON))
+
+	js, err = NewFromReaderNumber(bytes.NewReader(data))
+	assert.Equal(t, nil, err)
+	_, ok = js.Get("int").Interface().(json.Number)
+	assert.Equal(t, true, ok)
+}
+
+func TestNumber(t *testing.T) {
+	js, err := NewNumber([]byte(`{"big": 123456789012345678901234567890, "decimal": 0.10000000000000000000001, "string": "1"}`))
+	assert.Equal(t, nil, err)
+
+	n, ok := js.Get("big").CheckNumber()
+	assert.Equal(t, true, ok)
+	assert.Equal(t, json.Number("123456789012345678901234567890"), n)
+
+	assert.Equal(t, json.Number("0.10000000000000000000001"), js.Get("decimal").Number())
+
+	_, ok = js.Get("string").CheckNumber()
+	assert.Equal(t, false, ok)
+	assert.Equal(t, json.Number("42"), js.Get("missing").Number("42"))
+
+	// Numbers decoded as float64 can also be retrieved as json.Number
+	js, err = New([]byte(`{"float": 1.5}`))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, json.Number("1.5"), js.Get("float").Number())
+}
+
+func TestBigInt(t *testing.T) {
+	const digits = "1234567890123456789012345678901234567890"
+	js, err := NewNumber([]byte(`{"big": ` + digits + `, "exp": 1e3, "decimal": 1.5}`))
+	assert.Equal(t, nil, err)
+
+	i, ok := js.Get("big").CheckBigInt()
+	assert.Equal(t, true, ok)
+	assert.Equal(t, digits, i.String())
+
+	assert.Equal(t, "1000", js.Get("exp").BigInt().String())
+
+	_, ok = js.Get("decimal").CheckBigInt()
+	assert.Equal(t, false, ok)
+	assert.Equal(t, big.NewInt(42), js.Get("missing").BigInt(big.NewInt(42)))
+
+	// Round trip
+	js.Set("big2", i.Add(i, big.NewInt(1)))
+	newJSON, err := js.JSON()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, strings.Contains(string(newJSON), `"big":`+digits+`,`))
+	assert.Equal(t, true, strings.Contains(string(newJSON), `"big2":1234567890123456789012345678901234567891`))
+}
+
+func TestBigFloat(t *testing.T) {
+	const decimal = "12345.6789012345678901234567890"
+	js, err := NewNumber([]byte(`{"decimal": ` + decimal + `, "string": "1.5"}`))
+	assert.Equal(t, nil, err)
+
+	f, ok := js.Get("decimal").CheckBigFloat()
+	assert.Equal(t, true, ok)
+	expected, _, err := big.ParseFloat(decimal, 10, 200, big.ToNearestEven)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, f.SetPrec(100).Cmp(expected.SetPrec(100)))
+	assert.Equal(t, "12345.67890123456789012345678900", f.Text('f', 26))
+
+	// A float64 does not have enough precision for this
+	assert.NotEqual(t, "12345.67890123456789012345678900", big.NewFloat(js.Get("decimal").Float64()).Text('f', 26))
+
+	_, ok = js.Get("string").CheckBigFloat()
+	assert.Equal(t, false, ok)
+	assert.Equal(t, big.NewFloat(1.5), js.Get("missing").BigFloat(big.NewFloat(1.5)))
+}
+
+func TestSetRawJSON(t *testing.T) {
+	js, err := New([]byte(`{"a": {"list": [1, 2]}}`))
+	assert.Equal(t, nil, err)
+
+	err = js.SetRawJSON(".a.b.c", []byte(`{"d": "e"}`))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "e", js.GetNode(".a.b.c.d").String())
+
+	err = js.SetRawJSON(".a.list[1]", []byte(`["f", "g"]`))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "g", js.GetNode(".a.list[1]").Get(1).String())
+
+	newJSON, err := js.JSON()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `{"a":{"b":{"c":{"d":"e"}},"list":[1,["f","g"]]}}`, string(newJSON))
+
+	err = js.SetRawJSON(".a.b", []byte(`{"d": `))
+	assert.NotEqual(t, nil, err)
+	assert.Equal(t, "e", js.GetNode(".a.b.c.d").String())
+
+	// Empty JSON data is an error, not an empty list
+	err = js.SetRawJSON(".a.b", []byte(" "))
+	assert.NotEqual(t, nil, err)
+	assert.Equal(t, "e", js.GetNode(".a.b.c.d").String())
+
+	// Nothing is created if a later part of the path fails
+	err = js.SetRawJSON(".x.y[0]", []byte(`1`))
+	assert.NotEqual(t, nil, err)
+	err = js.SetRawJSON(".x.y[0].z", []byte(`1`))
+	assert.NotEqual(t, nil, err)
+	err = js.SetRawJSON(".a.list[5].z", []byte(`1`))
+	assert.NotEqual(t, nil, err)
+	assert.Equal(t, NilNode, js.Get("x"))
+	newJSON, err = js.JSON()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `{"a":{"b":{"c":{"d":"e"}},"list":[1,["f","g"]]}}`, string(newJSON))
+}
+
+func TestSanitizeUTF8(t *testing.T) {
+	js, err := New([]byte(`{"list": ["ok"]}`))
+	assert.Equal(t, nil, err)
+	js.Set("bad", "a\xffb")
+	js.Set("nested", map[string]interface{}{"k\xfe": []interface{}{"c\xc3", "fine"}})
+
+	assert.Equal(t, 3, js.SanitizeUTF8())
+	assert.Equal(t, "a�b", js.Get("bad").String())
+	assert.Equal(t, "c�", js.Get("nested", "k�", 0).String())
+	assert.Equal(t, "fine", js.Get("nested", "k�", 1).String())
+
+	// Nothing more to fix
+	assert.Equal(t, 0, js.SanitizeUTF8())
+
+	// The last invalid key in sorted order wins when sanitized keys collide
+	for i := 0; i < 10; i++ {
+		collision := &Node{map[string]interface{}{"k\xff": 1, "k\x80": 2, "k�": 3}}
+		assert.Equal(t, 2, collision.SanitizeUTF8())
+		assert.Equal(t, map[string]interface{}{"k�": 1}, collision.Map())
+	}
+}
+
+func TestPrecisionJSON(t *testing.T) {
+	js, err := New([]byte(`{"list": [300, 2.5]}`))
+	assert.Equal(t, nil, err)
+	js.Set("third", 1.0/3.0)
+
+	data, err := js.PrecisionJSON(4)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `{"list":[300,2.5],"third":0.3333}`, string(data))
+
+	// The document is not modified
+	data, err = js.JSON()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `{"list":[300,2.5],"third":0.3333333333333333}`, string(data))
+}
+
+func TestParseEmbedded(t *testing.T) {
+	js, err := New([]byte(`{"payload": "{\"a\": [1, 2]}", "list": ["[3]"], "invalid": "{"}`))
+	assert.Equal(t, nil, err)
+
+	err = js.ParseEmbedded(".payload")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, js.GetNode(".payload.a[1]").Int())
+
+	err = js.ParseEmbedded(".list[0]")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, js.GetNode(".list[0]").Get(0).Int())
+
+	err = js.ParseEmbedded(".invalid")
+	assert.NotEqual(t, nil, err)
+	assert.Equal(t, "{", js.GetNode(".invalid").String())
+
+	err = js.ParseEmbedded(".payload")
+	assert.NotEqual(t, nil, err)
+}
+
+func TestStringifyNode(t *testing.T) {
+	js, err := New([]byte(`{"payload": {"a": [1, 2]}}`))
+	assert.Equal(t, nil, err)
+
+	err = js.StringifyNode(".payload")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `{"a":[1,2]}`, js.GetNode(".payload").String())
+
+	newJSON, err := js.JSON()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `{"payload":"{\"a\":[1,2]}"}`, string(newJSON))
+
+	// Round trip
+	err = js.ParseEmbedded(".payload")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, js.GetNode(".payload.a[1]").Int())
+
+	err = js.StringifyNode(".missing")
+	assert.Equal(t, ErrKeyNotFound, err)
+}
+
+func TestLeafPaths(t *testing.T) {
+	js, err := New([]byte(`{"b": 3, "a": 2, "people": {"names": ["Bob", "Alice"]}, "empty": {}}`))
+	assert.Equal(t, nil, err)
+
+	paths := js.LeafPaths()
+	assert.Equal(t, []string{"x.a", "x.b", "x.people.names[0]", "x.people.names[1]"}, paths)
+	for _, path := range paths {
+		assert.NotEqual(t, NilNode, js.GetNode(path))
+	}
+
+	js, err = New([]byte(`"scalar"`))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"x"}, js.LeafPaths())
+}
+
+func TestParent(t *testing.T) {
+	js, err := New([]byte(`{"a": {"b": "c", "list": ["d", "e"]}}`))
+	assert.Equal(t, nil, err)
+
+	parent, err := js.Parent(".a.b")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"b", "list"}, parent.Keys())
+
+	parent, err = js.Parent(".a.list[1]")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []interface{}{"d", "e"}, parent.List())
+
+	parent, err = js.Parent(".a")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, js, parent)
+
+	_, err = js.Parent(".")
+	assert.Equal(t, ErrNoParent, err)
+	_, err = js.Parent(".a.missing")
+	assert.Equal(t, ErrKeyNotFound, err)
+}
+
+func TestGetWithParent(t *testing.T) {
+	js, err := New([]byte(`{"a": {"b": {"c": "d"}, "list": [1, 2]}}`))
+	assert.Equal(t, nil, err)
+
+	node, parent, err := js.GetWithParent(".a.b.c")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "d", node.String())
+	assert.Equal(t, js.GetNode(".a.b"), parent)
+
+	node, parent, err = js.GetWithParent(".a.list[1]")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, node.Int())
+	assert.Equal(t, js.GetNode(".a.list"), parent)
+
+	node, parent, err = js.GetWithParent(".")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, js, node)
+	assert.Equal(t, NilNode, parent)
+
+	_, _, err = js.GetWithParent(".a.missing")
+	assert.Equal(t, ErrKeyNotFound, err)
+}
+
+func TestRangeOrdered(t *testing.T) {
+	js, err := New([]byte(`{"c": 3, "a": 1, "d": 4, "b": 2}`))
+	assert.Equal(t, nil, err)
+
+	var keys []string
+	js.RangeOrdered(func(key string, n *Node) bool {
+		keys = append(keys, key)
+		return n.Int() < 3
+	})
+	assert.Equal(t, []string{"a", "b", "c"}, keys)
+}
+
+func TestDefaultGetters(t *testing.T) {
+	js, err := New([]byte(`{"port": 8080, "host": "localhost", "ratio": 0.5, "debug": true}`))
+	assert.Equal(t, nil, err)
+
+	port, ok := js.Get("port").IntDefault(80)
+	assert.Equal(t, 8080, port)
+	assert.Equal(t, true, ok)
+	port, ok = js.Get("missing").IntDefault(80)
+	assert.Equal(t, 80, port)
+	assert.Equal(t, false, ok)
+
+	host, ok := js.Get("host").StringDefault("example.com")
+	assert.Equal(t, "localhost", host)
+	assert.Equal(t, true, ok)
+	host, ok = js.Get("port").StringDefault("example.com")
+	assert.Equal(t, "example.com", host)
+	assert.Equal(t, false, ok)
+
+	ratio, ok := js.Get("ratio").Float64Default(1.0)
+	assert.Equal(t, 0.5, ratio)
+	assert.Equal(t, true, ok)
+	ratio, ok = js.Get("missing").Float64Default(1.0)
+	assert.Equal(t, 1.0, ratio)
+	assert.Equal(t, false, ok)
+
+	debug, ok := js.Get("debug").BoolDefault(false)
+	assert.Equal(t, true, debug)
+	assert.Equal(t, true, ok)
+	debug, ok = js.Get("missing").BoolDefault(false)
+	assert.Equal(t, false, debug)
+	assert.Equal(t, false, ok)
+}
+
+func TestTryGetters(t *testing.T) {
+	js, err := New([]byte(`{"port": 8080, "host": "localhost", "ratio": 0.5, "debug": true}`))
+	assert.Equal(t, nil, err)
+
+	port, err := js.Get("port").TryInt()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 8080, port)
+	_, err = js.Get("host").TryInt()
+	assert.NotEqual(t, nil, err)
+	_, err = js.Get("missing").TryInt()
+	assert.NotEqual(t, nil, err)
+
+	host, err := js.Get("host").TryString()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "localhost", host)
+	_, err = js.Get("port").TryString()
+	assert.NotEqual(t, nil, err)
+
+	ratio, err := js.Get("ratio").TryFloat64()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0.5, ratio)
+
+	i64, err := js.Get("port").TryInt64()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int64(8080), i64)
+	u64, err := js.Get("port").TryUint64()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, uint64(8080), u64)
+
+	debug, err := js.Get("debug").TryBool()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, debug)
+	_, err = js.Get("ratio").TryBool()
+	assert.NotEqual(t, nil, err)
+}
+
+func TestExtraDefaults(t *testing.T) {
+	js, err := New([]byte(`{"port": 8080}`))
+	assert.Equal(t, nil, err)
+
+	// Only the first default value is used, instead of panicking
+	assert.Equal(t, 1, js.Get("missing").Int(1, 2))
+	assert.Equal(t, 8080, js.Get("port").Int(1, 2))
+	assert.Equal(t, "a", js.Get("missing").String("a", "b"))
+	assert.Equal(t, true, js.Get("missing").Bool(true, false))
+	assert.Equal(t, 0, len(js.Get("missing").List(nil, []interface{}{1})))
+	assert.Equal(t, 1, len(js.Get("missing").Map(map[string]interface{}{"a": 1}, nil)))
+}
+
+func TestChunk(t *testing.T) {
+	js, err := New([]byte(`[1, 2, 3, 4, 5, 6, 7, 8, 9, 10]`))
+	assert.Equal(t, nil, err)
+
+	chunks := js.Chunk(3)
+	assert.Equal(t, 4, len(chunks))
+	for i, size := range []int{3, 3, 3, 1} {
+		assert.Equal(t, size, len(chunks[i].List()))
+	}
+	assert.Equal(t, 4, chunks[1].Get(0).Int())
+	assert.Equal(t, 10, chunks[3].Get(0).Int())
+
+	assert.Equal(t, 1, len(js.Chunk(20)))
+	assert.Equal(t, 0, len(NewNode().Chunk(3)))
+	assert.Equal(t, NodeList(nil), NewNode().Chunk(3))
+	assert.Equal(t, NodeList(nil), js.Chunk(0))
+}
+
+func TestGetAny(t *testing.T) {
+	js, err := New([]byte(`{"user_id": 42, "uid": 7, "name": null}`))
+	assert.Equal(t, nil, err)
+
+	assert.Equal(t, 42, js.GetAny("userId", "user_id", "uid").Int())
+	assert.Equal(t, NilNode, js.GetAny("userId", "id"))
+	assert.Equal(t, NilNode, js.GetAny())
+	// A null value counts as present
+	assert.Equal(t, true, js.GetAny("name", "user_id") != NilNode)
+	assert.Equal(t, NilNode, js.Get("user_id").GetAny("a"))
+}
+
+func TestDuplicateKeys(t *testing.T) {
+	// Maps can not hold duplicate keys, so the last value is kept, and
+	// the marshaled JSON has a single entry for the key
+	js, err := New([]byte(`{"a": 1, "b": 2, "a": 3}`))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, js.Get("a").Int())
+	data, err := js.JSON()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `{"a":3,"b":2}`, string(data))
+}
+
+func TestRawAt(t *testing.T) {
+	js, err := New([]byte(`{"envelope": {"payload": {"id": 7, "items": ["a", "b"]}, "signature": "abc"}}`))
+	assert.Equal(t, nil, err)
+
+	data, err := js.RawAt(".envelope.payload")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `{"id":7,"items":["a","b"]}`, string(data))
+	payload, err := New(data)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, js.GetNode(".envelope.payload"), payload)
+
+	_, err = js.RawAt(".envelope.missing")
+	assert.Equal(t, ErrKeyNotFound, err)
+	_, err = js.RawAt(".envelope[x]")
+	assert.NotEqual(t, nil, err)
+}
+
+func TestNewRawAt(t *testing.T) {
+	body := []byte(`{
+		"envelope": {
+			"payload": { "z": 1,  "a": [ 2, 3 ] },
+			"signature": "abc"
+		},
+		"list": [{"b": 1,  "a": 2}]
+	}`)
+	js, err := NewRawAt(body, ".envelope.payload", ".list[0]", ".missing")
+	assert.Equal(t, nil, err)
+
+	raw, ok := js.GetNode(".envelope.payload").Raw()
+	assert.Equal(t, true, ok)
+	assert.Equal(t, `{ "z": 1,  "a": [ 2, 3 ] }`, string(raw))
+	raw, ok = js.GetNode(".list[0]").Raw()
+	assert.Equal(t, true, ok)
+	assert.Equal(t, `{"b": 1,  "a": 2}`, string(raw))
+
+	// The rest of the document is decoded as usual
+	assert.Equal(t, "abc", js.GetNode(".envelope.signature").String())
+	_, ok = js.GetNode(".envelope.signature").Raw()
+	assert.Equal(t, false, ok)
+	_, ok = js.GetNode(".envelope").Raw()
+	assert.Equal(t, false, ok)
+
+	// Negative indexes count from the end
+	js, err = NewRawAt(body, ".list[-1]", ".list[-2]")
+	assert.Equal(t, nil, err)
+	raw, ok = js.GetNode(".list[0]").Raw()
+	assert.Equal(t, true, ok)
+	assert.Equal(t, `{"b": 1,  "a": 2}`, string(raw))
+
+	_, err = NewRawAt(body, ".list[x]")
+	assert.NotEqual(t, nil, err)
+}
+
+func TestGetMapAndList(t *testing.T) {
+	js, err := New([]byte(`{"db": {"host": "localhost", "ports": [5432, 5433]}}`))
+	assert.Equal(t, nil, err)
+
+	m, err := js.GetMap(".db")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(m))
+	assert.Equal(t, "localhost", m["host"].String())
+
+	l, err := js.GetList(".db.ports")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(l))
+	assert.Equal(t, 5433, l[1].Int())
+
+	_, err = js.GetMap(".db.ports")
+	assert.NotEqual(t, nil, err)
+	_, err = js.GetList(".db")
+	assert.NotEqual(t, nil, err)
+	_, err = js.GetMap(".missing")
+	assert.Equal(t, ErrKeyNotFound, err)
+	_, err = js.GetList(".missing")
+	assert.Equal(t, ErrKeyNotFound, err)
+}
+
+func TestUnwrap(t *testing.T) {
+	js, err := New([]byte(`{"one": ["x"], "two": ["x", "y"], "scalar": "z", "empty": []}`))
+	assert.Equal(t, nil, err)
+
+	assert.Equal(t, "x", js.Get("one").Unwrap().String())
+	assert.Equal(t, js.Get("two"), js.Get("two").Unwrap())
+	assert.Equal(t, "z", js.Get("scalar").Unwrap().String())
+	assert.Equal(t, 0, len(js.Get("empty").Unwrap().List()))
+}
+
+func TestAsList(t *testing.T) {
+	js, err := New([]byte(`{"scalar": "x", "object": {"a": 1}, "list": ["y", "z"]}`))
+	assert.Equal(t, nil, err)
+
+	l := js.Get("scalar").AsList().List()
+	assert.Equal(t, []interface{}{"x"}, l)
+	l = js.Get("object").AsList().List()
+	assert.Equal(t, 1, len(l))
+	assert.Equal(t, 1, js.Get("object").AsList().Get(0).Get("a").Int())
+	assert.Equal(t, js.Get("list"), js.Get("list").AsList())
+	assert.Equal(t, 0, len(js.Get("missing").AsList().List()))
+
+	// Unwrap is the opposite
+	assert.Equal(t, "x", js.Get("scalar").AsList().Unwrap().String())
+}
+
+func TestDepth(t *testing.T) {
+	js, err := New([]byte(`{"a": {"b": [1, {"c": "deep"}]}, "d": 2}`))
+	assert.Equal(t, nil, err)
+
+	assert.Equal(t, 4, js.MaxDepth())
+	assert.Equal(t, 0, (&Node{"scalar"}).MaxDepth())
+	assert.Equal(t, 0, NewNode().MaxDepth())
+
+	depth, err := js.Depth(".a.b[1].c")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 4, depth)
+	depth, err = js.Depth(".d")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, depth)
+	depth, err = js.Depth(".")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, depth)
+	_, err = js.Depth(".a.missing")
+	assert.Equal(t, ErrKeyNotFound, err)
+}
+
+func TestMinify(t *testing.T) {
+	body := []byte("{\n  \"z\": 12345678901234567890.000,\n  \"a\": [ 1e400, \"two words\" ],\n  \"b\": { }\n}\n")
+	data, err := Minify(body)
+	assert.Equal(t, nil, err)
+	// The numbers and the order of the keys are kept as they are
+	assert.Equal(t, `{"z":12345678901234567890.000,"a":[1e400,"two words"],"b":{}}`, string(data))
+
+	_, err = Minify([]byte(`{"a": }`))
+	assert.NotEqual(t, nil, err)
+}
+
+// minifyBenchmarkJSON returns an indented JSON document for the Minify benchmarks
+func minifyBenchmarkJSON() []byte {
+	data, err := json.MarshalIndent(bigListNode(), "", "  ")
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+func BenchmarkMinify(b *testing.B) {
+	data := minifyBenchmarkJSON()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Minify(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMinifyParse(b *testing.B) {
+	data := minifyBenchmarkJSON()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		js, err := NewNumber(data)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := js.JSON(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestUnwrapKey(t *testing.T) {
+	js, err := New([]byte(`{"data": {"x": 1}}`))
+	assert.Equal(t, nil, err)
+	data, err := js.UnwrapKey("data").JSON()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `{"x":1}`, string(data))
+	assert.Equal(t, js, js.UnwrapKey("result"))
+
+	multi, err := New([]byte(`{"data": {"x": 1}, "meta": {}}`))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, multi, multi.UnwrapKey("data"))
+	scalar := &Node{"data"}
+	assert.Equal(t, scalar, scalar.UnwrapKey("data"))
+}
+
+func TestNewNumberTrailingData(t *testing.T) {
+	for _, body := range []string{`{} garbage`, `{"a": 1} {"b": 2}`, `[1, 2`} {
+		js, err := NewNumber([]byte(body))
+		assert.NotEqual(t, nil, err)
+		assert.Equal(t, (*Node)(nil), js)
+		_, err = New([]byte(body))
+		assert.NotEqual(t, nil, err)
+	}
+	js, err := NewNumber([]byte("{\"a\": 1}\n  \n"))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, json.Number("1"), js.Get("a").Interface())
+}
+
+func TestNilNodeUnmodified(t *testing.T) {
+	other, err := New([]byte(`{"a": 1}`))
+	assert.Equal(t, nil, err)
+
+	missing := other.Get("missing")
+	missing.Merge(other)
+	missing.ApplyDefaults(other)
+	assert.Equal(t, ErrNilNode, missing.SetNode(".", "x"))
+	assert.Equal(t, ErrNilNode, missing.SetRawJSON(".", []byte(`{"b": 2}`)))
+	assert.NotEqual(t, nil, missing.ParseEmbedded("."))
+	assert.Equal(t, nil, NilNode.Interface())
+	assert.Equal(t, nil, other.Get("other").Interface())
+}
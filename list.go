@@ -0,0 +1,370 @@
+package jpath
+
+import (
+	"errors"
+	"sort"
+)
+
+// GroupBy groups the maps in a list by the value of the given key, and returns
+// a map from each value to a list of the maps with that value, like GROUP BY in SQL.
+// Since the groups are map keys, the values are converted to strings, so that the
+// number 1 and the string "1" end up in the same group. Elements that are not maps,
+// that lack the key, or where the value is null or "", are all grouped under "".
+func (j *Node) GroupBy(key string) (*Node, error) {
+	l, ok := j.CheckList()
+	if !ok {
+		return NilNode, errors.New("Can only group the elements of a list. Not a list: " + j.Info())
+	}
+	groups := make(map[string]interface{})
+	for _, elem := range l {
+		group := ""
+		if val, ok := (&Node{elem}).GetKey(key); ok {
+			if !isScalar(val.data) {
+				return NilNode, errors.New("Can only group by a string, number, bool or null: " + val.Info())
+			}
+			group = scalarString(val.data)
+		}
+		members, _ := groups[group].([]interface{})
+		groups[group] = append(members, elem)
+	}
+	return &Node{groups}, nil
+}
+
+// Pluck returns the value of the given key for each map in a list.
+// Elements that lack the key are skipped, unless includeMissing is true,
+// in which case NilNode is used in their place, so that the indexes match.
+func (j *Node) Pluck(key string, includeMissing bool) NodeList {
+	l, ok := j.CheckList()
+	if !ok {
+		return nil
+	}
+	var nodes NodeList
+	for _, elem := range l {
+		if val, ok := (&Node{elem}).GetKey(key); ok {
+			nodes = append(nodes, val)
+		} else if includeMissing {
+			nodes = append(nodes, NilNode)
+		}
+	}
+	return nodes
+}
+
+// Float64List returns the numbers in a list as float64s. If skipNonNumeric is true,
+// elements that are not numbers are skipped, if not, false is returned instead.
+func (j *Node) Float64List(skipNonNumeric bool) ([]float64, bool) {
+	l, ok := j.CheckList()
+	if !ok {
+		return nil, false
+	}
+	numbers := make([]float64, 0, len(l))
+	n := &Node{}
+	for _, elem := range l {
+		n.data = elem
+		f, ok := n.CheckFloat64()
+		if !ok {
+			if skipNonNumeric {
+				continue
+			}
+			return nil, false
+		}
+		numbers = append(numbers, f)
+	}
+	return numbers, true
+}
+
+// Sum returns the sum of the numbers in a list. If skipNonNumeric is true, elements
+// that are not numbers are skipped, if not, they make Sum return false.
+// Returns false if the node is not a list.
+func (j *Node) Sum(skipNonNumeric bool) (float64, bool) {
+	numbers, ok := j.Float64List(skipNonNumeric)
+	if !ok {
+		return 0, false
+	}
+	var sum float64
+	for _, f := range numbers {
+		sum += f
+	}
+	return sum, true
+}
+
+// Min returns the smallest number in a list. If skipNonNumeric is true, elements
+// that are not numbers are skipped, if not, they make Min return false.
+// Returns false if the node is not a list, or if there are no numbers.
+func (j *Node) Min(skipNonNumeric bool) (float64, bool) {
+	numbers, ok := j.Float64List(skipNonNumeric)
+	if !ok || len(numbers) == 0 {
+		return 0, false
+	}
+	min := numbers[0]
+	for _, f := range numbers[1:] {
+		if f < min {
+			min = f
+		}
+	}
+	return min, true
+}
+
+// Max returns the largest number in a list. If skipNonNumeric is true, elements
+// that are not numbers are skipped, if not, they make Max return false.
+// Returns false if the node is not a list, or if there are no numbers.
+func (j *Node) Max(skipNonNumeric bool) (float64, bool) {
+	numbers, ok := j.Float64List(skipNonNumeric)
+	if !ok || len(numbers) == 0 {
+		return 0, false
+	}
+	max := numbers[0]
+	for _, f := range numbers[1:] {
+		if f > max {
+			max = f
+		}
+	}
+	return max, true
+}
+
+// Avg returns the average of the numbers in a list. If skipNonNumeric is true, elements
+// that are not numbers are skipped, if not, they make Avg return false.
+// Returns false if the node is not a list, or if there are no numbers.
+func (j *Node) Avg(skipNonNumeric bool) (float64, bool) {
+	numbers, ok := j.Float64List(skipNonNumeric)
+	if !ok || len(numbers) == 0 {
+		return 0, false
+	}
+	var sum float64
+	for _, f := range numbers {
+		sum += f
+	}
+	return sum / float64(len(numbers)), true
+}
+
+// CompactList returns a copy of a list, without the null elements.
+// If recursive is true, nulls are also removed from lists within the list,
+// including lists that are within maps. Returns NilNode if the node is not a list.
+func (j *Node) CompactList(recursive bool) *Node {
+	l, ok := j.CheckList()
+	if !ok {
+		return NilNode
+	}
+	return &Node{compactList(l, recursive)}
+}
+
+// compactList returns a copy of the given list without null elements
+func compactList(l []interface{}, recursive bool) []interface{} {
+	compacted := make([]interface{}, 0, len(l))
+	for _, elem := range l {
+		if elem == nil {
+			continue
+		}
+		if recursive {
+			elem = compactNested(elem)
+		}
+		compacted = append(compacted, elem)
+	}
+	return compacted
+}
+
+// compactNested returns a copy of the given value, where nulls are removed from all lists
+func compactNested(v interface{}) interface{} {
+	switch v := v.(type) {
+	case *Node:
+		return compactNested(v.data)
+	case []interface{}:
+		return compactList(v, true)
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			m[key] = compactNested(val)
+		}
+		return m
+	}
+	return v
+}
+
+// FlattenOnce returns a copy of a list where the elements of lists within the list
+// are moved up one level, like [[1, 2], [3, [4]], 5] to [1, 2, 3, [4], 5].
+// Returns NilNode if the node is not a list.
+func (j *Node) FlattenOnce() *Node {
+	l, ok := j.CheckList()
+	if !ok {
+		return NilNode
+	}
+	return &Node{flattenList(l, 1)}
+}
+
+// FlattenDeep returns a copy of a list where all lists within the list, at any depth,
+// are replaced by their elements, like [[1, 2], [3, [4]], 5] to [1, 2, 3, 4, 5].
+// Returns NilNode if the node is not a list.
+func (j *Node) FlattenDeep() *Node {
+	l, ok := j.CheckList()
+	if !ok {
+		return NilNode
+	}
+	return &Node{flattenList(l, -1)}
+}
+
+// flattenList returns a copy of the given list, where lists within the list are
+// replaced by their elements, down to the given depth. A negative depth means no limit.
+func flattenList(l []interface{}, depth int) []interface{} {
+	flattened := make([]interface{}, 0, len(l))
+	for _, elem := range l {
+		if n, ok := elem.(*Node); ok {
+			elem = n.data
+		}
+		if sub, ok := elem.([]interface{}); ok && depth != 0 {
+			flattened = append(flattened, flattenList(sub, depth-1)...)
+			continue
+		}
+		flattened = append(flattened, deepCopy(elem))
+	}
+	return flattened
+}
+
+// Zip pairs the elements of two lists by index, and returns a list of
+// two-element lists, like [["a", 1], ["b", 2]]. If the lists have different
+// lengths, the result is as long as the shortest list, unless strict is true,
+// in which case an error is returned instead.
+func (j *Node) Zip(other *Node, strict bool) (*Node, error) {
+	a, ok := j.CheckList()
+	if !ok {
+		return NilNode, errors.New("Can only zip lists. Not a list: " + j.Info())
+	}
+	b, ok := other.CheckList()
+	if !ok {
+		return NilNode, errors.New("Can only zip lists. Not a list: " + other.Info())
+	}
+	if strict && len(a) != len(b) {
+		return NilNode, errors.New("Can only zip lists of the same length")
+	}
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	pairs := make([]interface{}, n)
+	for i := range pairs {
+		pairs[i] = []interface{}{a[i], b[i]}
+	}
+	return &Node{pairs}, nil
+}
+
+// SortedInsert inserts val into a list that is sorted according to less,
+// at the position that keeps the list sorted. The position is found with a binary
+// search, and val is inserted after any elements that are equal to it.
+// Only this node is updated, so for a list that is returned by Get, the document
+// that contains the list is not changed. Use SortedInsertAt for that.
+func (j *Node) SortedInsert(val interface{}, less func(a, b interface{}) bool) error {
+	return j.SortedInsertAt(".", val, less)
+}
+
+// SortedInsertAt inserts val into the sorted list at the given JSON path, like SortedInsert.
+// The list is replaced with a longer list in its parent, or in this node for the root node.
+//
+//	err := document.SortedInsertAt(".scores", 4, less)
+func (j *Node) SortedInsertAt(JSONpath string, val interface{}, less func(a, b interface{}) bool) error {
+	cp, err := compileSinglePath(JSONpath)
+	if err != nil {
+		return err
+	}
+	node, parent := j.getNodes(cp)
+	l, ok := node.CheckList()
+	if !ok {
+		return errors.New("Can only insert into a list. Not a list: " + node.Info())
+	}
+	if n, ok := val.(*Node); ok {
+		val = n.data
+	}
+	i := sort.Search(len(l), func(i int) bool {
+		return less(val, l[i])
+	})
+	// Allocate a new list, so that a list that shares the same array is not modified
+	inserted := make([]interface{}, len(l)+1)
+	copy(inserted, l[:i])
+	inserted[i] = val
+	copy(inserted[i+1:], l[i:])
+	if len(cp.segments) == 0 {
+		j.data = inserted
+		return nil
+	}
+	return parent.setSegment(cp.segments[len(cp.segments)-1], inserted)
+}
+
+// containsValue checks if the list contains a value that is equal to v, in the same way as Equals
+func containsValue(l []interface{}, v interface{}) bool {
+	for _, elem := range l {
+		if valuesEqual(elem, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// setOperation returns a new list with the unique elements of the list in this node,
+// and then of the other list if includeOther is true, for which keep returns true
+func (j *Node) setOperation(other *Node, includeOther bool, keep func(v interface{}, inOther bool) bool) *Node {
+	a, ok := j.CheckList()
+	if !ok {
+		return NilNode
+	}
+	b, ok := other.CheckList()
+	if !ok {
+		return NilNode
+	}
+	result := []interface{}{}
+	for _, elem := range a {
+		if !containsValue(result, elem) && keep(elem, containsValue(b, elem)) {
+			result = append(result, deepCopy(elem))
+		}
+	}
+	if includeOther {
+		for _, elem := range b {
+			if !containsValue(result, elem) {
+				result = append(result, deepCopy(elem))
+			}
+		}
+	}
+	return &Node{result}
+}
+
+// Union returns a new list with the unique elements of both lists, in order,
+// starting with the elements of this list. Elements are compared in the same way as Equals.
+// Returns NilNode if one of the nodes is not a list.
+func (j *Node) Union(other *Node) *Node {
+	return j.setOperation(other, true, func(v interface{}, inOther bool) bool {
+		return true
+	})
+}
+
+// Intersect returns a new list with the unique elements of this list that are
+// also in the other list, in order. Elements are compared in the same way as Equals.
+// Returns NilNode if one of the nodes is not a list.
+func (j *Node) Intersect(other *Node) *Node {
+	return j.setOperation(other, false, func(v interface{}, inOther bool) bool {
+		return inOther
+	})
+}
+
+// Difference returns a new list with the unique elements of this list that are
+// not in the other list, in order. Elements are compared in the same way as Equals.
+// Returns NilNode if one of the nodes is not a list.
+func (j *Node) Difference(other *Node) *Node {
+	return j.setOperation(other, false, func(v interface{}, inOther bool) bool {
+		return !inOther
+	})
+}
+
+// Partition splits a list into two new lists, one with the elements that pred
+// returns true for, and one with the rest. The order of the elements is kept.
+// Returns NilNode for both if the node is not a list.
+func (j *Node) Partition(pred func(n *Node) bool) (match *Node, rest *Node) {
+	l, ok := j.CheckList()
+	if !ok {
+		return NilNode, NilNode
+	}
+	matched, others := []interface{}{}, []interface{}{}
+	for _, elem := range l {
+		if pred(&Node{elem}) {
+			matched = append(matched, elem)
+		} else {
+			others = append(others, elem)
+		}
+	}
+	return &Node{matched}, &Node{others}
+}
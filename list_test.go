@@ -0,0 +1,245 @@
+package jpath
+
+import (
+	"testing"
+
+	"github.com/bmizerany/assert"
+)
+
+func TestGroupBy(t *testing.T) {
+	js, err := New([]byte(`[
+		{"name": "apple", "category": "fruit"},
+		{"name": "carrot", "category": "vegetable"},
+		{"name": "banana", "category": "fruit"},
+		{"name": "rock"}
+	]`))
+	assert.Equal(t, nil, err)
+
+	groups, err := js.GroupBy("category")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"", "fruit", "vegetable"}, groups.Keys())
+	assert.Equal(t, 2, len(groups.Get("fruit").List()))
+	assert.Equal(t, "banana", groups.Get("fruit", 1, "name").String())
+	assert.Equal(t, "carrot", groups.Get("vegetable", 0, "name").String())
+	assert.Equal(t, "rock", groups.Get("", 0, "name").String())
+
+	_, err = js.Get(0).GroupBy("category")
+	assert.NotEqual(t, nil, err)
+
+	// The values are converted to strings
+	mixed, err := New([]byte(`[{"id": 1}, {"id": "1"}, {"id": null}, {"id": ""}, {}, "not a map"]`))
+	assert.Equal(t, nil, err)
+	groups, err = mixed.GroupBy("id")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"", "1"}, groups.Keys())
+	assert.Equal(t, 2, len(groups.Get("1").List()))
+	assert.Equal(t, 4, len(groups.Get("").List()))
+}
+
+func TestPluck(t *testing.T) {
+	js, err := New([]byte(`[{"id": 1}, {"name": "no id"}, {"id": 3}]`))
+	assert.Equal(t, nil, err)
+
+	ids := js.Pluck("id", false)
+	assert.Equal(t, 2, len(ids))
+	assert.Equal(t, 1, ids[0].Int())
+	assert.Equal(t, 3, ids[1].Int())
+
+	ids = js.Pluck("id", true)
+	assert.Equal(t, 3, len(ids))
+	assert.Equal(t, NilNode, ids[1])
+	assert.Equal(t, 3, ids[2].Int())
+
+	assert.Equal(t, 0, len(js.Get(0).Pluck("id", false)))
+}
+
+func TestAggregates(t *testing.T) {
+	js, err := New([]byte(`{"numbers": [1, 2, 3], "mixed": [1, "two", 3], "empty": [], "strings": ["a"]}`))
+	assert.Equal(t, nil, err)
+
+	numbers := js.Get("numbers")
+	for _, skip := range []bool{false, true} {
+		sum, ok := numbers.Sum(skip)
+		assert.Equal(t, true, ok)
+		assert.Equal(t, 6.0, sum)
+		min, ok := numbers.Min(skip)
+		assert.Equal(t, true, ok)
+		assert.Equal(t, 1.0, min)
+		max, ok := numbers.Max(skip)
+		assert.Equal(t, true, ok)
+		assert.Equal(t, 3.0, max)
+		avg, ok := numbers.Avg(skip)
+		assert.Equal(t, true, ok)
+		assert.Equal(t, 2.0, avg)
+	}
+
+	mixed := js.Get("mixed")
+	_, ok := mixed.Sum(false)
+	assert.Equal(t, false, ok)
+	_, ok = mixed.Min(false)
+	assert.Equal(t, false, ok)
+	_, ok = mixed.Max(false)
+	assert.Equal(t, false, ok)
+	_, ok = mixed.Avg(false)
+	assert.Equal(t, false, ok)
+	floats, ok := mixed.Float64List(true)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, []float64{1, 3}, floats)
+
+	// Skip the elements that are not numbers
+	sum, ok := mixed.Sum(true)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, 4.0, sum)
+	min, ok := mixed.Min(true)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, 1.0, min)
+	max, ok := mixed.Max(true)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, 3.0, max)
+	avg, ok := mixed.Avg(true)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, 2.0, avg)
+
+	sum, ok = js.Get("empty").Sum(false)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, 0.0, sum)
+	_, ok = js.Get("empty").Min(false)
+	assert.Equal(t, false, ok)
+	_, ok = js.Get("strings").Avg(true)
+	assert.Equal(t, false, ok)
+}
+
+func TestCompactList(t *testing.T) {
+	js, err := New([]byte(`{"list": [1, null, 2, null], "nested": [null, [3, null], {"a": [null, 4]}]}`))
+	assert.Equal(t, nil, err)
+
+	assert.Equal(t, []interface{}{1.0, 2.0}, js.Get("list").CompactList(false).List())
+
+	data, err := js.Get("nested").CompactList(false).JSON()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `[[3,null],{"a":[null,4]}]`, string(data))
+
+	data, err = js.Get("nested").CompactList(true).JSON()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `[[3],{"a":[4]}]`, string(data))
+
+	// The original is not modified
+	assert.Equal(t, 4, len(js.Get("list").List()))
+	assert.Equal(t, NilNode, js.CompactList(true))
+}
+
+func TestZip(t *testing.T) {
+	names, err := New([]byte(`["a", "b"]`))
+	assert.Equal(t, nil, err)
+	ages, err := New([]byte(`[1, 2, 3]`))
+	assert.Equal(t, nil, err)
+
+	zipped, err := names.Zip(ages, false)
+	assert.Equal(t, nil, err)
+	data, err := zipped.JSON()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `[["a",1],["b",2]]`, string(data))
+
+	_, err = names.Zip(ages, true)
+	assert.NotEqual(t, nil, err)
+	_, err = names.Zip(NewNode(), false)
+	assert.NotEqual(t, nil, err)
+}
+
+func TestSortedInsert(t *testing.T) {
+	js, err := New([]byte(`[10, 20, 30]`))
+	assert.Equal(t, nil, err)
+
+	less := func(a, b interface{}) bool {
+		return (&Node{a}).Float64() < (&Node{b}).Float64()
+	}
+	assert.Equal(t, nil, js.SortedInsert(25, less))
+	assert.Equal(t, nil, js.SortedInsert(5, less))
+	assert.Equal(t, nil, js.SortedInsert(35, less))
+	assert.Equal(t, nil, js.SortedInsert(20.0, less))
+
+	values, ok := js.Float64List(false)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, []float64{5, 10, 20, 20, 25, 30, 35}, values)
+
+	// A nested list is replaced in its parent, and other lists that share
+	// the same array are not modified
+	nested, err := New([]byte(`{"scores": [1, 3, 5], "other": {"scores": [0]}}`))
+	assert.Equal(t, nil, err)
+	scores := nested.Get("scores").List()[:2]
+	assert.Equal(t, nil, nested.SortedInsertAt(".scores", 4, less))
+	assert.Equal(t, nil, nested.SortedInsertAt(".scores", 2, less))
+	values, ok = nested.Get("scores").Float64List(false)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, []float64{1, 2, 3, 4, 5}, values)
+	assert.Equal(t, []interface{}{1.0, 3.0}, scores)
+	assert.Equal(t, nil, nested.SortedInsertAt(".other.scores", -1, less))
+	assert.Equal(t, -1, nested.GetNode(".other.scores[0]").Int())
+
+	// Only the node is updated, not the document that contains the list
+	scoresNode := nested.Get("scores")
+	assert.Equal(t, nil, scoresNode.SortedInsert(6, less))
+	assert.Equal(t, 6, len(scoresNode.List()))
+	assert.Equal(t, 5, len(nested.Get("scores").List()))
+
+	assert.NotEqual(t, nil, NewNode().SortedInsert(1, less))
+	assert.NotEqual(t, nil, nested.SortedInsertAt(".missing", 1, less))
+	assert.NotEqual(t, nil, nested.SortedInsertAt(".scores[x]", 1, less))
+}
+
+func TestSetOperations(t *testing.T) {
+	a, err := New([]byte(`[1, "b", {"c": 3}, 1, 4]`))
+	assert.Equal(t, nil, err)
+	b, err := New([]byte(`[4, {"c": 3}, 5, 5]`))
+	assert.Equal(t, nil, err)
+
+	jsonOf := func(n *Node) string {
+		data, err := n.JSON()
+		assert.Equal(t, nil, err)
+		return string(data)
+	}
+	assert.Equal(t, `[1,"b",{"c":3},4,5]`, jsonOf(a.Union(b)))
+	assert.Equal(t, `[{"c":3},4]`, jsonOf(a.Intersect(b)))
+	assert.Equal(t, `[1,"b"]`, jsonOf(a.Difference(b)))
+	assert.Equal(t, `[5]`, jsonOf(b.Difference(a)))
+	assert.Equal(t, `[]`, jsonOf(a.Intersect(&Node{[]interface{}{}})))
+	assert.Equal(t, NilNode, a.Union(NewNode()))
+}
+
+func TestFlatten(t *testing.T) {
+	js, err := New([]byte(`[[1, 2], [3], 4]`))
+	assert.Equal(t, nil, err)
+	data, err := js.FlattenOnce().JSON()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `[1,2,3,4]`, string(data))
+
+	nested, err := New([]byte(`[[1, [2, [3, [4]]]], {"a": [5]}, [], 6]`))
+	assert.Equal(t, nil, err)
+	data, err = nested.FlattenOnce().JSON()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `[1,[2,[3,[4]]],{"a":[5]},6]`, string(data))
+	data, err = nested.FlattenDeep().JSON()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `[1,2,3,4,{"a":[5]},6]`, string(data))
+
+	assert.Equal(t, NilNode, NewNode().FlattenOnce())
+}
+
+func TestPartition(t *testing.T) {
+	js, err := New([]byte(`[1, 2, 3, 4, 5, 6]`))
+	assert.Equal(t, nil, err)
+
+	evens, odds := js.Partition(func(n *Node) bool {
+		return n.Int()%2 == 0
+	})
+	values, ok := evens.Float64List(false)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, []float64{2, 4, 6}, values)
+	values, ok = odds.Float64List(false)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, []float64{1, 3, 5}, values)
+
+	match, rest := NewNode().Partition(func(n *Node) bool { return true })
+	assert.Equal(t, NilNode, match)
+	assert.Equal(t, NilNode, rest)
+}
@@ -0,0 +1,142 @@
+package jpath
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"sort"
+	"unicode/utf8"
+)
+
+// OrderedJSON returns its marshaled data as `[]byte`, where the keys of every map
+// that are listed in keyOrder come first, in the given order, followed by the
+// remaining keys in sorted order. Useful for writing JSON that is meant to be
+// read by humans, like {"name": ..., "version": ..., "dependencies": ...}.
+func (j *Node) OrderedJSON(keyOrder []string) ([]byte, error) {
+	rank := make(map[string]int, len(keyOrder))
+	for i, key := range keyOrder {
+		if _, ok := rank[key]; !ok {
+			rank[key] = i
+		}
+	}
+	var buf bytes.Buffer
+	if err := writeOrdered(&buf, j.data, rank, 0); err != nil {
+		return []byte{}, err
+	}
+	return buf.Bytes(), nil
+}
+
+// LimitedJSON returns its marshaled data as `[]byte`, like JSON, but stops encoding
+// as soon as the output is longer than maxBytes. If the output was cut, true is
+// returned together with the first maxBytes bytes, which are not valid JSON.
+func (j *Node) LimitedJSON(maxBytes int) ([]byte, bool, error) {
+	if maxBytes < 0 {
+		maxBytes = 0
+	}
+	var buf bytes.Buffer
+	err := writeOrdered(&buf, j.data, nil, maxBytes+1)
+	if err == errOutputLimit || buf.Len() > maxBytes {
+		return buf.Bytes()[:maxBytes], true, nil
+	} else if err != nil {
+		return []byte{}, false, err
+	}
+	return buf.Bytes(), false, nil
+}
+
+// OneLine returns the node as compact JSON on a single line, for use in log messages.
+// If the JSON is longer than maxLen bytes, it is cut and ends with "...",
+// so that the returned string is at most maxLen bytes long.
+// If maxLen is 0 or negative, an empty string is returned.
+func (j *Node) OneLine(maxLen int) string {
+	const ellipsis = "..."
+	if maxLen <= 0 {
+		return ""
+	}
+	data, truncated, err := j.LimitedJSON(maxLen)
+	if err != nil {
+		return ""
+	}
+	if !truncated {
+		return string(data)
+	}
+	if maxLen <= len(ellipsis) {
+		return ellipsis[:maxLen]
+	}
+	data = data[:maxLen-len(ellipsis)]
+	// Do not cut a multi-byte UTF-8 character in half
+	for len(data) > 0 && !utf8.Valid(data) {
+		data = data[:len(data)-1]
+	}
+	return string(data) + ellipsis
+}
+
+// orderedKeys returns the keys of the given map, with the keys that are
+// in rank first, ordered by rank, followed by the remaining keys in sorted order
+func orderedKeys(m map[string]interface{}, rank map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(a, b int) bool {
+		ra, okA := rank[keys[a]]
+		rb, okB := rank[keys[b]]
+		switch {
+		case okA && okB:
+			return ra < rb
+		case okA != okB:
+			return okA
+		}
+		return keys[a] < keys[b]
+	})
+	return keys
+}
+
+// errOutputLimit is returned by writeOrdered when the output is too long
+var errOutputLimit = errors.New("output limit reached")
+
+// writeOrdered writes the given value as JSON, with map keys ordered by orderedKeys.
+// If limit is larger than 0, errOutputLimit is returned as soon as the output is at least limit bytes long.
+func writeOrdered(buf *bytes.Buffer, v interface{}, rank map[string]int, limit int) error {
+	if limit > 0 && buf.Len() >= limit {
+		return errOutputLimit
+	}
+	switch v := v.(type) {
+	case *Node:
+		return writeOrdered(buf, v.data, rank, limit)
+	case map[string]interface{}:
+		buf.WriteByte('{')
+		for i, key := range orderedKeys(v, rank) {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			b, err := json.Marshal(key)
+			if err != nil {
+				return err
+			}
+			buf.Write(b)
+			buf.WriteByte(':')
+			if err := writeOrdered(buf, v[key], rank, limit); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, val := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeOrdered(buf, val, rank, limit); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		buf.Write(b)
+	}
+	return nil
+}
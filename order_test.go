@@ -0,0 +1,70 @@
+package jpath
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bmizerany/assert"
+)
+
+func TestOrderedJSON(t *testing.T) {
+	js, err := New([]byte(`{"dependencies": {"b": "1.0", "a": "2.0"}, "version": "1.2.3", "author": "Alice", "name": "example", "plugins": [{"version": 2, "name": "p"}]}`))
+	assert.Equal(t, nil, err)
+
+	data, err := js.OrderedJSON([]string{"name", "version"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `{"name":"example","version":"1.2.3","author":"Alice","dependencies":{"a":"2.0","b":"1.0"},"plugins":[{"name":"p","version":2}]}`, string(data))
+
+	// Without a key order, the keys are sorted, like for JSON
+	data, err = js.OrderedJSON(nil)
+	assert.Equal(t, nil, err)
+	expected, err := js.JSON()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, string(expected), string(data))
+}
+
+func TestLimitedJSON(t *testing.T) {
+	js, err := New([]byte(`{"a": 1, "b": [1, 2, 3]}`))
+	assert.Equal(t, nil, err)
+
+	data, truncated, err := js.LimitedJSON(100)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, truncated)
+	assert.Equal(t, `{"a":1,"b":[1,2,3]}`, string(data))
+
+	// Exactly the same length as the output
+	data, truncated, err = js.LimitedJSON(19)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, truncated)
+	assert.Equal(t, `{"a":1,"b":[1,2,3]}`, string(data))
+
+	// The output is cut, and is not valid JSON
+	data, truncated, err = js.LimitedJSON(10)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, truncated)
+	assert.Equal(t, `{"a":1,"b"`, string(data))
+}
+
+func TestOneLine(t *testing.T) {
+	js, err := New([]byte(`{"a": 1, "b": [1, 2, 3]}`))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `{"a":1,"b":[1,2,3]}`, js.OneLine(80))
+
+	large := NewNode()
+	for i := 0; i < 1000; i++ {
+		large.Set(fmt.Sprintf("key%d", i), "line one\nline two")
+	}
+	preview := large.OneLine(40)
+	assert.Equal(t, 40, len(preview))
+	assert.Equal(t, true, strings.HasSuffix(preview, "..."))
+	assert.Equal(t, false, strings.Contains(preview, "\n"))
+
+	// Multi-byte characters are not cut in half
+	utf, err := New([]byte(`["æøå"]`))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `["æ...`, utf.OneLine(8))
+	assert.Equal(t, "..", utf.OneLine(2))
+	assert.Equal(t, "", utf.OneLine(0))
+	assert.Equal(t, "", utf.OneLine(-1))
+}
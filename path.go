@@ -0,0 +1,475 @@
+package jpath
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// segmentKind is the kind of step that a pathSegment represents
+type segmentKind int
+
+const (
+	keySegment      segmentKind = iota // a map key, like .name
+	indexSegment                       // a list index, like [1]
+	wildcardSegment                    // all elements of a list or map, like [*]
+	sliceSegment                       // a range of list elements, like [1:3]
+)
+
+// pathSegment is a single step in a compiled JSON path
+type pathSegment struct {
+	kind  segmentKind
+	key   string
+	index int
+	end   int // the end of a slice, used together with index as the start
+	// hasStart and hasEnd are false if the start or end of a slice is left out, like [:2] or [2:]
+	hasStart, hasEnd bool
+}
+
+// CompiledPath is a JSON path expression that has been parsed once and can be reused
+type CompiledPath struct {
+	path     string
+	segments []pathSegment
+	multi    bool // true if the path may match more than one node
+}
+
+// PathError is returned for JSON paths with invalid syntax
+type PathError struct {
+	Path     string // the JSON path
+	Position int    // the position of the invalid part of the JSON path, starting at 0
+	Msg      string // a description of what is wrong
+}
+
+// Error returns a description of the error, including the position and the JSON path
+func (e *PathError) Error() string {
+	return fmt.Sprintf("%s at position %d in %q", e.Msg, e.Position, e.Path)
+}
+
+// MissingPathsError is returned by RequireAll, and lists all JSON paths that were not found
+type MissingPathsError struct {
+	Paths []string // the JSON paths that were not found, in the order they were given
+}
+
+// Error returns a description of the error, including all the missing JSON paths
+func (e *MissingPathsError) Error() string {
+	return "missing required JSON paths: " + strings.Join(e.Paths, ", ")
+}
+
+// ErrMultiplePath is for when a path that may match several nodes is used where only one node is expected
+var ErrMultiplePath = errors.New("the JSON path may match several nodes, use GetEach or GetAll instead")
+
+// maxCachedPaths is the number of compiled JSON paths that are kept in the cache
+const maxCachedPaths = 1024
+
+// pathCache contains compiled JSON paths, keyed by the JSON path string.
+// The cache is emptied when it is full, so that JSON paths that are built
+// dynamically, like ".items[42]", do not make it grow without limit.
+var pathCache = struct {
+	sync.RWMutex
+	paths map[string]*CompiledPath
+}{paths: make(map[string]*CompiledPath)}
+
+// CompilePath parses the given JSON path expression and returns a *CompiledPath
+// that can be used with GetCompiled, without parsing the path again.
+// Returns an error if the syntax of the JSON path is invalid.
+//
+// The leading "x" or "." of a JSON path is optional, so that "x.people.names[0]",
+// ".people.names[0]" and "people.names[0]" all refer to the same node.
+// "x", "." and "" all refer to the root node.
+// A name after a "." is always a map key, even if it is numeric, while
+// an integer within brackets is always a list index, and negative indexes count from the end.
+// A backslash escapes the following character, so that "a\[b\]" refers to the key "a[b]".
+// "[*]" matches all elements of a list, or all values of a map, sorted by key.
+// "[1:3]" matches the elements from index 1 up to, but not including, index 3.
+// The start or end of a slice may be left out, and negative bounds count from the end.
+func CompilePath(JSONpath string) (*CompiledPath, error) {
+	if JSONpath == "x" || JSONpath == "" || JSONpath == "." {
+		// The root node
+		return &CompiledPath{path: JSONpath}, nil
+	}
+	cp := &CompiledPath{path: JSONpath}
+	original := JSONpath
+	// shift is the number of characters that are added to the JSON path before parsing
+	shift := 0
+	// JSON path starting with x[ is a special case.
+	if strings.HasPrefix(JSONpath, "x[") {
+		// Add a "." between "x" and "[".
+		JSONpath = "x." + JSONpath[1:]
+		shift = 1
+	}
+	// offset is the position of the next part in the JSON path
+	offset := 0
+	for i, part := range splitPath(JSONpath) {
+		start := offset
+		offset += len(part) + 1
+		if i > 0 {
+			start -= shift
+		}
+		if i == 0 && (part == "" || part == "x") {
+			// The root node
+			continue
+		}
+		if part == "" {
+			return nil, &PathError{original, start, "empty name"}
+		}
+		bracket := indexUnescaped(part, '[')
+		if bracket < 0 {
+			cp.segments = append(cp.segments, pathSegment{key: unescapePath(part)})
+			continue
+		}
+		name := part[:bracket]
+		secondpart := part[bracket+1:]
+		bracketPos := start + len(name)
+		if !strings.Contains(secondpart, "]") {
+			return nil, &PathError{original, bracketPos, "missing ]"}
+		}
+		fields := strings.SplitN(secondpart, "]", 2)
+		stringIndex := fields[0]
+		if fields[1] != "" {
+			return nil, &PathError{original, bracketPos + len(stringIndex) + 2, "unexpected characters after ]"}
+		}
+		if name != "" {
+			cp.segments = append(cp.segments, pathSegment{key: unescapePath(name)})
+		}
+		if stringIndex == "*" {
+			cp.segments = append(cp.segments, pathSegment{kind: wildcardSegment})
+			cp.multi = true
+			continue
+		}
+		if strings.Contains(stringIndex, ":") {
+			seg, ok := parseSlice(stringIndex)
+			if !ok {
+				return nil, &PathError{original, bracketPos, "malformed slice"}
+			}
+			cp.segments = append(cp.segments, seg)
+			cp.multi = true
+			continue
+		}
+		index, err := strconv.Atoi(stringIndex)
+		if err != nil {
+			return nil, &PathError{original, bracketPos, "malformed index"}
+		}
+		cp.segments = append(cp.segments, pathSegment{kind: indexSegment, index: index})
+	}
+	return cp, nil
+}
+
+// splitPath splits a JSON path on each "." that is not escaped with a backslash
+func splitPath(JSONpath string) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(JSONpath); i++ {
+		switch JSONpath[i] {
+		case '\\':
+			// Skip the escaped character
+			i++
+		case '.':
+			parts = append(parts, JSONpath[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, JSONpath[start:])
+}
+
+// indexUnescaped returns the index of the first c in s that is not escaped with a backslash, or -1
+func indexUnescaped(s string, c byte) int {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case c:
+			return i
+		}
+	}
+	return -1
+}
+
+// unescapePath removes the backslashes that escape characters in a JSON path name
+func unescapePath(name string) string {
+	if !strings.Contains(name, "\\") {
+		return name
+	}
+	var sb strings.Builder
+	for i := 0; i < len(name); i++ {
+		if name[i] == '\\' && i+1 < len(name) {
+			i++
+		}
+		sb.WriteByte(name[i])
+	}
+	return sb.String()
+}
+
+// escapePath escapes the characters in a map key that have a special meaning in JSON paths
+func escapePath(key string) string {
+	if !strings.ContainsAny(key, ".[]\\") {
+		return key
+	}
+	var sb strings.Builder
+	for i := 0; i < len(key); i++ {
+		switch key[i] {
+		case '.', '[', ']', '\\':
+			sb.WriteByte('\\')
+		}
+		sb.WriteByte(key[i])
+	}
+	return sb.String()
+}
+
+// parseSlice parses a slice expression like "1:3", ":2" or "-2:"
+func parseSlice(s string) (pathSegment, bool) {
+	seg := pathSegment{kind: sliceSegment}
+	fields := strings.SplitN(s, ":", 2)
+	var err error
+	if fields[0] != "" {
+		if seg.index, err = strconv.Atoi(fields[0]); err != nil {
+			return seg, false
+		}
+		seg.hasStart = true
+	}
+	if fields[1] != "" {
+		if seg.end, err = strconv.Atoi(fields[1]); err != nil {
+			return seg, false
+		}
+		seg.hasEnd = true
+	}
+	return seg, true
+}
+
+// bounds returns the start and end indexes of a slice segment, for a list of the given length
+func (seg pathSegment) bounds(length int) (int, int) {
+	clamp := func(i int) int {
+		if i < 0 {
+			i += length
+		}
+		if i < 0 {
+			return 0
+		}
+		if i > length {
+			return length
+		}
+		return i
+	}
+	start, end := 0, length
+	if seg.hasStart {
+		start = clamp(seg.index)
+	}
+	if seg.hasEnd {
+		end = clamp(seg.end)
+	}
+	return start, end
+}
+
+// matches checks if the given path segment matches the given map key, or the
+// given index in a list of the given length. For maps, index is -1.
+func (seg pathSegment) matches(key string, index, length int) bool {
+	switch seg.kind {
+	case wildcardSegment:
+		return true
+	case keySegment:
+		return index < 0 && seg.key == key
+	case indexSegment:
+		i := seg.index
+		if i < 0 {
+			i += length
+		}
+		return index >= 0 && i == index
+	case sliceSegment:
+		start, end := seg.bounds(length)
+		return index >= start && index < end
+	}
+	return false
+}
+
+// resolveIndexes returns a copy of the given path segments, where negative list
+// indexes are replaced with the indexes they refer to within this node
+func (j *Node) resolveIndexes(segments []pathSegment) []pathSegment {
+	resolved := make([]pathSegment, len(segments))
+	copy(resolved, segments)
+	n := j
+	for i, seg := range resolved {
+		if seg.kind != indexSegment {
+			n = n.Get(seg.key)
+			continue
+		}
+		if l, ok := n.CheckList(); ok && seg.index < 0 {
+			resolved[i].index += len(l)
+		}
+		n = n.Get(resolved[i].index)
+	}
+	return resolved
+}
+
+// compilePathCached returns a compiled JSON path, using a cache of previously compiled paths
+func compilePathCached(JSONpath string) (*CompiledPath, error) {
+	pathCache.RLock()
+	cp, ok := pathCache.paths[JSONpath]
+	pathCache.RUnlock()
+	if ok {
+		return cp, nil
+	}
+	cp, err := CompilePath(JSONpath)
+	if err != nil {
+		return nil, err
+	}
+	pathCache.Lock()
+	if len(pathCache.paths) >= maxCachedPaths {
+		pathCache.paths = make(map[string]*CompiledPath)
+	}
+	pathCache.paths[JSONpath] = cp
+	pathCache.Unlock()
+	return cp, nil
+}
+
+// ValidatePath checks the syntax of the given JSON path, without evaluating it.
+// Returns an error for unterminated brackets, invalid indexes and empty names.
+func ValidatePath(JSONpath string) error {
+	_, err := CompilePath(JSONpath)
+	return err
+}
+
+// compileSinglePath returns a compiled JSON path, or ErrMultiplePath
+// if the JSON path may match more than one node
+func compileSinglePath(JSONpath string) (*CompiledPath, error) {
+	cp, err := compilePathCached(JSONpath)
+	if err != nil {
+		return nil, err
+	}
+	if cp.multi {
+		return nil, ErrMultiplePath
+	}
+	return cp, nil
+}
+
+// String returns the JSON path expression that was compiled
+func (cp *CompiledPath) String() string {
+	return cp.path
+}
+
+// getNodes will find the node (and parent node) that the compiled path leads to
+func (j *Node) getNodes(cp *CompiledPath) (*Node, *Node) {
+	if len(cp.segments) == 0 {
+		return j, NilNode
+	}
+	n, parent := j, j
+	for _, seg := range cp.segments {
+		parent = n
+		switch seg.kind {
+		case indexSegment:
+			n = n.Get(seg.index)
+		case keySegment:
+			n = n.Get(seg.key)
+		default:
+			n = NilNode
+		}
+	}
+	return n, parent
+}
+
+// GetCompiled will find the JSON node that corresponds to the given compiled JSON path.
+// Returns ErrKeyNotFound if there is no node at the given path.
+func (j *Node) GetCompiled(cp *CompiledPath) (*Node, error) {
+	if cp == nil {
+		return NilNode, errors.New("no compiled JSON path")
+	}
+	if cp.multi {
+		return NilNode, ErrMultiplePath
+	}
+	node, _ := j.getNodes(cp)
+	if node == NilNode {
+		return NilNode, ErrKeyNotFound
+	}
+	return node, nil
+}
+
+// RequireAll checks that all the given JSON paths can be found in the document.
+// Returns a *MissingPathsError that lists every missing JSON path, or
+// a *PathError if one of the JSON paths is invalid. Nulls count as present.
+func (j *Node) RequireAll(paths ...string) error {
+	var missing []string
+	for _, JSONpath := range paths {
+		cp, err := compileSinglePath(JSONpath)
+		if err != nil {
+			return err
+		}
+		if n, _ := j.getNodes(cp); n == NilNode {
+			missing = append(missing, JSONpath)
+		}
+	}
+	if len(missing) > 0 {
+		return &MissingPathsError{missing}
+	}
+	return nil
+}
+
+// each calls fn for each node that the given path segments lead to, in order
+func (j *Node) each(segments []pathSegment, fn func(n *Node) error) error {
+	if len(segments) == 0 {
+		return fn(j)
+	}
+	seg, rest := segments[0], segments[1:]
+	switch seg.kind {
+	case wildcardSegment:
+		if l, ok := j.CheckList(); ok {
+			for _, val := range l {
+				if err := (&Node{val}).each(rest, fn); err != nil {
+					return err
+				}
+			}
+		} else if m, ok := j.CheckMap(); ok {
+			for _, key := range j.Keys() {
+				if err := (&Node{m[key]}).each(rest, fn); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	case sliceSegment:
+		l, ok := j.CheckList()
+		if !ok {
+			return nil
+		}
+		start, end := seg.bounds(len(l))
+		for i := start; i < end; i++ {
+			if err := (&Node{l[i]}).each(rest, fn); err != nil {
+				return err
+			}
+		}
+		return nil
+	case indexSegment:
+		if n, ok := j.GetIndex(seg.index); ok {
+			return n.each(rest, fn)
+		}
+	case keySegment:
+		if n, ok := j.GetKey(seg.key); ok {
+			return n.each(rest, fn)
+		}
+	}
+	return nil
+}
+
+// GetEach calls fn for each node that matches the given JSON path, in document order.
+// The JSON path may contain wildcards, like "x.books[*].author".
+// Stops and returns the error if fn returns an error.
+func (j *Node) GetEach(JSONpath string, fn func(n *Node) error) error {
+	cp, err := compilePathCached(JSONpath)
+	if err != nil {
+		return err
+	}
+	return j.each(cp.segments, fn)
+}
+
+// GetAll returns all nodes that match the given JSON path, in document order.
+// The JSON path may contain wildcards, like "x.books[*].author".
+func (j *Node) GetAll(JSONpath string) (NodeList, error) {
+	var nodes NodeList
+	err := j.GetEach(JSONpath, func(n *Node) error {
+		nodes = append(nodes, n)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
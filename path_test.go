@@ -0,0 +1,335 @@
+package jpath
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/bmizerany/assert"
+)
+
+const pathTestJSON = `{
+	"test": {
+		"string_list": ["asdf", "ghjk", "zxcv"],
+		"listwithsubs": [{"subkeyone": 1},
+		{"subkeytwo": 2, "subkeythree": 3}],
+		"sub_obj": {"a": 1}
+	}
+}`
+
+func TestCompiledPath(t *testing.T) {
+	js, err := New([]byte(pathTestJSON))
+	assert.Equal(t, nil, err)
+
+	paths := []string{
+		"x",
+		"",
+		"x.test",
+		"x.test.string_list[1]",
+		".test.listwithsubs.[1].subkeytwo",
+		"x.test.sub_obj.a",
+		"x.test.missing",
+		"test",
+	}
+	for _, path := range paths {
+		cp, err := CompilePath(path)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, path, cp.String())
+
+		// Cached and uncached lookups should give the same result
+		uncached, _ := js.GetCompiled(cp)
+		cached := js.GetNode(path)
+		cachedAgain := js.GetNode(path)
+		assert.Equal(t, uncached, cached)
+		assert.Equal(t, cached, cachedAgain)
+	}
+
+	_, err = CompilePath("x.test.string_list[a]")
+	assert.NotEqual(t, nil, err)
+	_, _, err = js.GetNodes("x.test.string_list[a]")
+	assert.NotEqual(t, nil, err)
+
+	// The cache does not grow without limit
+	for i := 0; i < 2*maxCachedPaths; i++ {
+		js.GetNode(fmt.Sprintf(".test.string_list[%d]", i))
+	}
+	pathCache.RLock()
+	assert.Equal(t, true, len(pathCache.paths) <= maxCachedPaths)
+	pathCache.RUnlock()
+}
+
+func TestCompiledPathReuse(t *testing.T) {
+	cp, err := CompilePath("x.books[1].author")
+	assert.Equal(t, nil, err)
+
+	documents := []string{
+		`{"books": [{"author": "A"}, {"author": "B"}]}`,
+		`{"books": [{"author": "C"}, {"author": "D"}, {"author": "E"}]}`,
+		`{"books": [{"author": "F"}]}`,
+	}
+	expected := []string{"B", "D", ""}
+	for i, document := range documents {
+		js, err := New([]byte(document))
+		assert.Equal(t, nil, err)
+		n, err := js.GetCompiled(cp)
+		if expected[i] == "" {
+			assert.Equal(t, ErrKeyNotFound, err)
+			assert.Equal(t, NilNode, n)
+			continue
+		}
+		assert.Equal(t, nil, err)
+		assert.Equal(t, expected[i], n.String())
+	}
+
+	_, err = CompilePath("x.books[1.author")
+	assert.NotEqual(t, nil, err)
+}
+
+func BenchmarkGetNode(b *testing.B) {
+	js, err := New([]byte(pathTestJSON))
+	if err != nil {
+		b.Fatal(err)
+	}
+	for i := 0; i < b.N; i++ {
+		js.GetNode(".test.listwithsubs.[1].subkeytwo")
+	}
+}
+
+func BenchmarkGetCompiled(b *testing.B) {
+	js, err := New([]byte(pathTestJSON))
+	if err != nil {
+		b.Fatal(err)
+	}
+	cp, err := CompilePath(".test.listwithsubs.[1].subkeytwo")
+	if err != nil {
+		b.Fatal(err)
+	}
+	for i := 0; i < b.N; i++ {
+		js.GetCompiled(cp)
+	}
+}
+
+func BenchmarkCompilePath(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		CompilePath(".test.listwithsubs.[1].subkeytwo")
+	}
+}
+
+func TestRelativePath(t *testing.T) {
+	js, err := New([]byte(`{"a": {"b": "c", "list": ["d", "e"]}}`))
+	assert.Equal(t, nil, err)
+
+	assert.Equal(t, "c", js.GetNode(".a.b").String())
+	assert.Equal(t, "c", js.GetNode("a.b").String())
+	assert.Equal(t, "c", js.GetNode("x.a.b").String())
+	assert.Equal(t, "e", js.GetNode(".a.list[1]").String())
+	assert.Equal(t, "e", js.GetNode("a.list[1]").String())
+	assert.Equal(t, js.GetNode(".a"), js.GetNode("a"))
+}
+
+func TestRootPath(t *testing.T) {
+	js, err := New([]byte(`{"a": {"b": "c", "list": ["d", "e"]}}`))
+	assert.Equal(t, nil, err)
+
+	assert.Equal(t, js, js.GetNode("."))
+	assert.Equal(t, js, js.GetNode(""))
+	assert.Equal(t, js, js.GetNode("x"))
+
+	assert.Equal(t, nil, js.SetNode(".a.list[1]", "f"))
+	assert.Equal(t, "f", js.GetNode(".a.list[1]").String())
+	assert.Equal(t, nil, js.SetNode(".a.b", "g"))
+	assert.Equal(t, "g", js.GetNode(".a.b").String())
+	assert.NotEqual(t, nil, js.SetNode(".a.list[2]", "h"))
+	assert.NotEqual(t, nil, js.SetNode(".a.b.c", "h"))
+
+	assert.Equal(t, nil, js.SetNode(".", map[string]interface{}{"z": "y"}))
+	assert.Equal(t, "y", js.GetNode(".z").String())
+	assert.Equal(t, NilNode, js.GetNode(".a"))
+}
+
+func TestNumericKeys(t *testing.T) {
+	js, err := New([]byte(`{"123": "map value", "0": {"1": "nested"}, "list": ["first", "second"]}`))
+	assert.Equal(t, nil, err)
+
+	assert.Equal(t, "map value", js.GetNode(".123").String())
+	assert.Equal(t, "map value", js.GetNode("123").String())
+	assert.Equal(t, "nested", js.GetNode(".0.1").String())
+	assert.Equal(t, NilNode, js.GetNode("[0]"))
+	assert.Equal(t, "first", js.GetNode(".list[0]").String())
+	assert.Equal(t, NilNode, js.GetNode(".list.0"))
+}
+
+func TestGetEach(t *testing.T) {
+	js, err := New([]byte(`{"books": [
+		{"author": "A", "tags": ["x", "y"]},
+		{"title": "No author"},
+		{"author": "B", "tags": ["z"]}
+	], "ids": {"b": 2, "a": 1}}`))
+	assert.Equal(t, nil, err)
+
+	var authors []string
+	err = js.GetEach("x.books[*].author", func(n *Node) error {
+		authors = append(authors, n.String())
+		return nil
+	})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"A", "B"}, authors)
+
+	tags, err := js.GetAll(".books[*].tags[*]")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, len(tags))
+	assert.Equal(t, "z", tags[2].String())
+
+	// Map values are visited in sorted key order
+	ids, err := js.GetAll(".ids[*]")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(ids))
+	assert.Equal(t, 1, ids[0].Int())
+
+	// Stop at the first error
+	errStop := errors.New("stop")
+	count := 0
+	err = js.GetEach("x.books[*]", func(n *Node) error {
+		count++
+		return errStop
+	})
+	assert.Equal(t, errStop, err)
+	assert.Equal(t, 1, count)
+
+	// Wildcards are not supported when only one node is expected
+	_, _, err = js.GetNodes("x.books[*].author")
+	assert.Equal(t, ErrMultiplePath, err)
+}
+
+func TestSliceRange(t *testing.T) {
+	js, err := New([]byte(`{"items": ["a", "b", "c", "d"]}`))
+	assert.Equal(t, nil, err)
+
+	values := func(path string) []string {
+		nodes, err := js.GetAll(path)
+		assert.Equal(t, nil, err)
+		var result []string
+		for _, n := range nodes {
+			result = append(result, n.String())
+		}
+		return result
+	}
+
+	assert.Equal(t, []string{"b", "c"}, values(".items[1:3]"))
+	assert.Equal(t, []string{"a", "b"}, values(".items[:2]"))
+	assert.Equal(t, []string{"c", "d"}, values(".items[2:]"))
+	assert.Equal(t, []string{"c", "d"}, values(".items[-2:]"))
+	assert.Equal(t, []string{"a", "b", "c"}, values(".items[:-1]"))
+	assert.Equal(t, []string{"a", "b", "c", "d"}, values(".items[:]"))
+	assert.Equal(t, 0, len(values(".items[3:1]")))
+	assert.Equal(t, 0, len(values(".items[10:]")))
+
+	_, err = CompilePath(".items[1:x]")
+	assert.NotEqual(t, nil, err)
+	_, _, err = js.GetNodes(".items[1:3]")
+	assert.Equal(t, ErrMultiplePath, err)
+}
+
+func TestValidatePath(t *testing.T) {
+	for _, path := range []string{"x", ".", "", "a", ".a.b[1]", "x[0].a", "a[*].b", "a[1:2]"} {
+		assert.Equal(t, nil, ValidatePath(path))
+	}
+	for _, path := range []string{".a.b[1", ".a[x]", ".a..b", "a.", ".a[1:y]", ".a[1]junk", ".a[1][2]"} {
+		assert.NotEqual(t, nil, ValidatePath(path))
+	}
+}
+
+func TestPathErrorPosition(t *testing.T) {
+	cases := []struct {
+		path     string
+		position int
+	}{
+		{".a.b[x].c", 4},
+		{"x[y]", 1},
+		{"x.a[1:z]", 3},
+		{".a..b", 3},
+		{"abc.d[1", 5},
+		{".a[1]b", 5},
+		{"x[0][1]", 4},
+	}
+	for _, tc := range cases {
+		err := ValidatePath(tc.path)
+		pathErr, ok := err.(*PathError)
+		assert.Equal(t, true, ok)
+		assert.Equal(t, tc.position, pathErr.Position)
+		assert.Equal(t, tc.path, pathErr.Path)
+	}
+	assert.Equal(t, `malformed index at position 4 in ".a.b[x].c"`, ValidatePath(".a.b[x].c").Error())
+}
+
+func TestEscapedPath(t *testing.T) {
+	js, err := New([]byte(`{"a[0]": "brackets", "a": ["index"], "b.c": {"d\\e": "dot and backslash"}}`))
+	assert.Equal(t, nil, err)
+
+	assert.Equal(t, "brackets", js.GetNode(`.a\[0\]`).String())
+	assert.Equal(t, "index", js.GetNode(`.a[0]`).String())
+	assert.Equal(t, "dot and backslash", js.GetNode(`.b\.c.d\\e`).String())
+
+	// Paths from LeafPaths are escaped
+	for _, path := range js.LeafPaths() {
+		assert.NotEqual(t, NilNode, js.GetNode(path))
+	}
+
+	assert.Equal(t, nil, js.DelKey(`.a\[0\]`))
+	assert.Equal(t, NilNode, js.Get("a[0]"))
+}
+
+func TestRequireAll(t *testing.T) {
+	js, err := New([]byte(`{"db": {"host": "localhost", "password": null}, "port": 8080}`))
+	assert.Equal(t, nil, err)
+
+	assert.Equal(t, nil, js.RequireAll(".db.host", ".db.password", ".port"))
+
+	err = js.RequireAll(".db.host", ".db.user", ".port", ".log.level")
+	missingErr, ok := err.(*MissingPathsError)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, []string{".db.user", ".log.level"}, missingErr.Paths)
+	assert.Equal(t, "missing required JSON paths: .db.user, .log.level", err.Error())
+
+	_, ok = js.RequireAll(".db[x]").(*PathError)
+	assert.Equal(t, true, ok)
+}
+
+func TestNegativeIndex(t *testing.T) {
+	js, err := New([]byte(`{"items": ["a", "b", "c"]}`))
+	assert.Equal(t, nil, err)
+
+	assert.Equal(t, "c", js.GetNode(".items[-1]").String())
+	assert.Equal(t, "a", js.GetNode(".items[-3]").String())
+	assert.Equal(t, NilNode, js.GetNode(".items[-4]"))
+	assert.Equal(t, "b", js.Get("items", -2).String())
+
+	assert.Equal(t, nil, js.SetNode(".items[-1]", "z"))
+	assert.Equal(t, "z", js.GetNode(".items[2]").String())
+	assert.NotEqual(t, nil, js.SetNode(".items[-4]", "z"))
+
+	nested, err := New([]byte(`{"rows": [{"id": 1}, {"id": 2}], "tags": ["a", "b"]}`))
+	assert.Equal(t, nil, err)
+	depth, err := nested.Depth(".rows[-1].id")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, depth)
+	_, err = nested.Depth(".rows[-3].id")
+	assert.Equal(t, ErrKeyNotFound, err)
+
+	row, err := nested.GetOrCreate(".rows[-1]")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, row.Get("id").Int())
+	_, err = nested.GetOrCreate(".rows[-3].x")
+	assert.NotEqual(t, nil, err)
+
+	jsonOf := func(n *Node) string {
+		data, err := n.JSON()
+		assert.Equal(t, nil, err)
+		return string(data)
+	}
+	assert.Equal(t, `{"rows":[null,{"id":2}]}`, jsonOf(nested.PickPaths(".rows[-1]")))
+	assert.Equal(t, `{"tags":["a"]}`, jsonOf(nested.PickPaths(".tags[-2]", ".tags[-3]")))
+	assert.Equal(t, `{"rows":[{"id":1}],"tags":["a","b"]}`, jsonOf(nested.OmitPaths(".rows[-1]")))
+	assert.Equal(t, `{"rows":[],"tags":["a","b"]}`, jsonOf(nested.OmitPaths(".rows[-1]", ".rows[-1]", ".rows[-1]")))
+	assert.Equal(t, `{"rows":[{"id":1},{}],"tags":["b"]}`, jsonOf(nested.Prune(".rows[-1].id", ".tags[-2]", ".tags[-5]")))
+}
@@ -0,0 +1,225 @@
+package jpath
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// queryOperators are the comparison operators that can be used in select,
+// with the two character operators first, so that they are found before "<" and ">"
+var queryOperators = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+// queryStage is one step in a query pipeline
+type queryStage struct {
+	path     *CompiledPath // the JSON path to follow, or the left side of a comparison in select
+	isSelect bool          // true for select(...)
+	// For select, op is the comparison operator and value is the right side of the comparison.
+	// If op is empty, select keeps the nodes where path leads to a value that is not null or false.
+	op    string
+	value interface{}
+}
+
+// Query evaluates a small subset of the jq query language, and returns the resulting nodes.
+// The supported expressions are:
+//
+//	.a.b                          follows the keys "a" and "b"
+//	.a[1], .a[1:3]                list indexes and slices, as for GetAll
+//	.a[]                          iterates over all elements of a list, or values of a map
+//	.a | .b                       passes all results of the left side to the right side
+//	select(.price < 10)           keeps the nodes where the comparison is true
+//	select(.available)            keeps the nodes where the value is not null or false
+//
+// The comparison operators are ==, !=, <, <=, > and >=, and the right side of a
+// comparison must be a JSON string, number, boolean or null. For example:
+//
+//	titles, err := js.Query(`.store.book[] | select(.price < 10) | .title`)
+func (j *Node) Query(expr string) (NodeList, error) {
+	stages, err := parseQuery(expr)
+	if err != nil {
+		return nil, err
+	}
+	nodes := NodeList{j}
+	for _, stage := range stages {
+		var next NodeList
+		for _, n := range nodes {
+			if stage.isSelect {
+				if stage.keep(n) {
+					next = append(next, n)
+				}
+				continue
+			}
+			n.each(stage.path.segments, func(result *Node) error {
+				next = append(next, result)
+				return nil
+			})
+		}
+		nodes = next
+	}
+	return nodes, nil
+}
+
+// parseQuery parses a query expression into a list of stages
+func parseQuery(expr string) ([]queryStage, error) {
+	parts, err := splitQuery(expr)
+	if err != nil {
+		return nil, err
+	}
+	stages := make([]queryStage, 0, len(parts))
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if strings.HasPrefix(part, "select(") && strings.HasSuffix(part, ")") {
+			stage, err := parseSelect(part[len("select(") : len(part)-1])
+			if err != nil {
+				return nil, err
+			}
+			stages = append(stages, stage)
+			continue
+		}
+		cp, err := compileQueryPath(part)
+		if err != nil {
+			return nil, err
+		}
+		stages = append(stages, queryStage{path: cp})
+	}
+	return stages, nil
+}
+
+// splitQuery splits a query expression on each "|" that is not within a string
+func splitQuery(expr string) ([]string, error) {
+	var parts []string
+	start, inString := 0, false
+	for i := 0; i < len(expr); i++ {
+		switch {
+		case inString && expr[i] == '\\':
+			// Skip the escaped character
+			i++
+		case expr[i] == '"':
+			inString = !inString
+		case !inString && expr[i] == '|':
+			parts = append(parts, expr[start:i])
+			start = i + 1
+		}
+	}
+	if inString {
+		return nil, errors.New("unterminated string in query: " + expr)
+	}
+	return append(parts, expr[start:]), nil
+}
+
+// compileQueryPath compiles a jq style path, like ".a.b[]", to a JSON path, like ".a.b[*]"
+func compileQueryPath(path string) (*CompiledPath, error) {
+	if !strings.HasPrefix(path, ".") {
+		return nil, errors.New("a query path must start with a \".\": " + path)
+	}
+	return CompilePath(strings.ReplaceAll(path, "[]", "[*]"))
+}
+
+// parseSelect parses the condition within select(...)
+func parseSelect(cond string) (queryStage, error) {
+	stage := queryStage{isSelect: true}
+	left, right := cond, ""
+	op, i := indexOperator(cond)
+	if i >= 0 {
+		stage.op = op
+		left, right = cond[:i], cond[i+len(op):]
+	}
+	cp, err := compileQueryPath(strings.TrimSpace(left))
+	if err != nil {
+		return stage, err
+	}
+	stage.path = cp
+	if stage.op == "" {
+		return stage, nil
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(right)), &stage.value); err != nil {
+		return stage, errors.New("invalid value in select: " + right)
+	}
+	if !isScalar(stage.value) {
+		return stage, errors.New("can only compare with a string, number, bool or null: " + right)
+	}
+	return stage, nil
+}
+
+// indexOperator returns the first comparison operator in cond that is not within a string,
+// and its position, or -1 if there is none
+func indexOperator(cond string) (string, int) {
+	inString := false
+	for i := 0; i < len(cond); i++ {
+		switch {
+		case inString && cond[i] == '\\':
+			// Skip the escaped character
+			i++
+		case cond[i] == '"':
+			inString = !inString
+		case !inString:
+			for _, op := range queryOperators {
+				if strings.HasPrefix(cond[i:], op) {
+					return op, i
+				}
+			}
+		}
+	}
+	return "", -1
+}
+
+// keep checks if the select condition is true for any of the nodes that the path leads to
+func (stage queryStage) keep(n *Node) bool {
+	found := false
+	n.each(stage.path.segments, func(result *Node) error {
+		if stage.op == "" {
+			found = result.data != nil && result.data != false
+		} else {
+			found = compareValues(result.data, stage.op, stage.value)
+		}
+		if found {
+			// Stop at the first match
+			return errQueryMatch
+		}
+		return nil
+	})
+	return found
+}
+
+// errQueryMatch is used for stopping the search for nodes when a match has been found
+var errQueryMatch = errors.New("match")
+
+// compareValues compares two values with the given operator. Numbers and strings can
+// be ordered, while other values can only be compared with == and !=.
+func compareValues(a interface{}, op string, b interface{}) bool {
+	switch op {
+	case "==":
+		return valuesEqual(a, b)
+	case "!=":
+		return !valuesEqual(a, b)
+	}
+	var cmp int
+	if isNumber(a) && isNumber(b) {
+		fa, _ := (&Node{a}).CheckFloat64()
+		fb, _ := (&Node{b}).CheckFloat64()
+		switch {
+		case fa < fb:
+			cmp = -1
+		case fa > fb:
+			cmp = 1
+		}
+	} else {
+		sa, okA := a.(string)
+		sb, okB := b.(string)
+		if !okA || !okB {
+			return false
+		}
+		cmp = strings.Compare(sa, sb)
+	}
+	switch op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package jpath
+
+import (
+	"testing"
+
+	"github.com/bmizerany/assert"
+)
+
+const queryTestJSON = `{
+	"store": {
+		"book": [
+			{"title": "Sayings of the Century", "price": 8.95, "available": true},
+			{"title": "Sword of Honour", "price": 12.99, "available": false},
+			{"title": "Moby Dick", "price": 8.99},
+			{"title": "The Lord of the Rings", "price": 22.99, "available": true}
+		],
+		"bicycle": {"color": "red", "price": 19.95}
+	}
+}`
+
+func TestQuery(t *testing.T) {
+	js, err := New([]byte(queryTestJSON))
+	assert.Equal(t, nil, err)
+
+	strings := func(nodes NodeList) []string {
+		var result []string
+		for _, n := range nodes {
+			result = append(result, n.String())
+		}
+		return result
+	}
+
+	titles, err := js.Query(`.store.book[] | select(.price < 10) | .title`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"Sayings of the Century", "Moby Dick"}, strings(titles))
+
+	titles, err = js.Query(`.store.book[] | select(.available) | .title`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"Sayings of the Century", "The Lord of the Rings"}, strings(titles))
+
+	titles, err = js.Query(`.store.book[] | select(.title == "Moby Dick") | .title`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"Moby Dick"}, strings(titles))
+
+	// Map values are iterated over in sorted key order
+	prices, err := js.Query(`.store | .bicycle | .[]`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(prices))
+	assert.Equal(t, "red", prices[0].String())
+
+	nodes, err := js.Query(`.`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, NodeList{js}, nodes)
+
+	// Operators within strings are part of the value
+	ops, err := New([]byte(`[{"n": "a==b"}, {"n": "<c>"}, {"n": "d"}]`))
+	assert.Equal(t, nil, err)
+	names, err := ops.Query(`.[] | select(.n != "a==b") | .n`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"<c>", "d"}, strings(names))
+	names, err = ops.Query(`.[] | select(.n == "<c>") | .n`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"<c>"}, strings(names))
+	names, err = ops.Query(`.[] | select(.n >= "a\"<") | .n`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"a==b", "d"}, strings(names))
+
+	for _, expr := range []string{`store`, `.store | select(.price < )`, `.store | select(.a == "b)`, `.a[x]`, `.a | select(.b == [1])`} {
+		_, err = js.Query(expr)
+		assert.NotEqual(t, nil, err)
+	}
+}
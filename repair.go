@@ -0,0 +1,134 @@
+package jpath
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+// RepairJSON tries to fix common errors in almost valid JSON, and returns JSON that
+// can be used with New. The errors that are fixed are unquoted keys and values,
+// like {name: "Bob"}, strings in single quotes, trailing commas, like [1, 2,],
+// and missing commas between values, like [1 2] or {"a": 1 "b": 2}.
+// Returns an error if the result is still not valid JSON.
+func RepairJSON(body []byte) ([]byte, error) {
+	var (
+		out        bytes.Buffer
+		whitespace bytes.Buffer // whitespace after a value or a comma, written after any comma
+		// pendingComma is true if a comma has been read, but not written yet,
+		// since it should be dropped if it is followed by } or ]
+		pendingComma bool
+		// needComma is true right after a value, where a comma is needed before the next value
+		needComma bool
+	)
+	body = bytes.TrimPrefix(body, bomUTF8)
+	// beginValue is called before writing the start of a string, number, map or list
+	beginValue := func() {
+		if pendingComma || needComma {
+			out.WriteByte(',')
+		}
+		out.Write(whitespace.Bytes())
+		whitespace.Reset()
+		pendingComma, needComma = false, false
+	}
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if pendingComma || needComma {
+				whitespace.WriteByte(c)
+			} else {
+				out.WriteByte(c)
+			}
+		case c == ',':
+			pendingComma, needComma = true, false
+		case c == ':':
+			out.Write(whitespace.Bytes())
+			whitespace.Reset()
+			out.WriteByte(c)
+			pendingComma, needComma = false, false
+		case c == '}' || c == ']':
+			// Drop a trailing comma, but keep the whitespace
+			out.Write(whitespace.Bytes())
+			whitespace.Reset()
+			out.WriteByte(c)
+			pendingComma, needComma = false, true
+		case c == '{' || c == '[':
+			beginValue()
+			out.WriteByte(c)
+		case c == '"' || c == '\'':
+			beginValue()
+			i = repairString(&out, body, i)
+			needComma = true
+		case c == '-' || (c >= '0' && c <= '9'):
+			beginValue()
+			for ; i < len(body) && bytes.IndexByte([]byte("0123456789+-.eE"), body[i]) >= 0; i++ {
+				out.WriteByte(body[i])
+			}
+			i--
+			needComma = true
+		case isWordByte(c):
+			beginValue()
+			start := i
+			for i < len(body) && isWordByte(body[i]) {
+				i++
+			}
+			word := string(body[start:i])
+			i--
+			if word == "true" || word == "false" || word == "null" {
+				out.WriteString(word)
+			} else {
+				// An unquoted key or value
+				quoted, _ := json.Marshal(word)
+				out.Write(quoted)
+			}
+			needComma = true
+		default:
+			out.Write(whitespace.Bytes())
+			whitespace.Reset()
+			out.WriteByte(c)
+		}
+	}
+	out.Write(whitespace.Bytes())
+	if !json.Valid(out.Bytes()) {
+		return nil, errors.New("could not repair the JSON data")
+	}
+	return out.Bytes(), nil
+}
+
+// isWordByte checks if the given byte can be part of an unquoted key
+func isWordByte(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// repairString writes the string that starts at body[start] to out, as a string in
+// double quotes, and returns the index of the closing quote. The string may be in
+// single or double quotes.
+func repairString(out *bytes.Buffer, body []byte, start int) int {
+	quote := body[start]
+	out.WriteByte('"')
+	i := start + 1
+	for ; i < len(body) && body[i] != quote; i++ {
+		c := body[i]
+		switch {
+		case c == '\\' && i+1 < len(body):
+			i++
+			if body[i] == '\'' {
+				// \' is not a valid escape sequence in JSON
+				out.WriteByte('\'')
+			} else {
+				out.WriteByte('\\')
+				out.WriteByte(body[i])
+			}
+		case c == '"':
+			// A double quote within a string in single quotes
+			out.WriteString(`\"`)
+		case c == '\n':
+			out.WriteString(`\n`)
+		default:
+			out.WriteByte(c)
+		}
+	}
+	out.WriteByte('"')
+	return i
+}
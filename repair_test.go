@@ -0,0 +1,34 @@
+package jpath
+
+import (
+	"testing"
+
+	"github.com/bmizerany/assert"
+)
+
+func TestRepairJSON(t *testing.T) {
+	cases := []struct {
+		input, expected string
+	}{
+		// Unquoted keys
+		{`{name: "Bob", age_2: 42}`, `{"name": "Bob", "age_2": 42}`},
+		// Single quotes
+		{`{'name': 'Bob "The Builder"', 'it\'s': true}`, `{"name": "Bob \"The Builder\"", "it's": true}`},
+		// Trailing commas
+		{"{\"a\": [1, 2,],\n}", "{\"a\": [1, 2]\n}"},
+		// Missing commas
+		{`{"a": 1 "b": [1 2 {"c": null} [3]]}`, `{"a": 1, "b": [1, 2, {"c": null}, [3]]}`},
+		// Valid JSON is not changed
+		{`{"a": [1.5e3, -2, "x,]"], "b": {}}`, `{"a": [1.5e3, -2, "x,]"], "b": {}}`},
+	}
+	for _, tc := range cases {
+		repaired, err := RepairJSON([]byte(tc.input))
+		assert.Equal(t, nil, err)
+		assert.Equal(t, tc.expected, string(repaired))
+		_, err = New(repaired)
+		assert.Equal(t, nil, err)
+	}
+
+	_, err := RepairJSON([]byte(`{"a": }`))
+	assert.NotEqual(t, nil, err)
+}
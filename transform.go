@@ -0,0 +1,765 @@
+package jpath
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// RemapKeys returns a copy of the node where all map keys, recursively,
+// have been replaced by the result of calling fn with the key. If several keys
+// in a map give the same new key, the value of the last key, in sorted order, is used.
+func (j *Node) RemapKeys(fn func(key string) string) *Node {
+	return &Node{remapKeys(j.data, fn)}
+}
+
+// remapKeys returns a copy of the given value, with all map keys replaced by fn(key)
+func remapKeys(v interface{}, fn func(key string) string) interface{} {
+	switch v := v.(type) {
+	case *Node:
+		return remapKeys(v.data, fn)
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		// Visit the keys in sorted order, so that the result does not depend on the map order
+		for _, key := range (&Node{v}).Keys() {
+			m[fn(key)] = remapKeys(v[key], fn)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, val := range v {
+			l[i] = remapKeys(val, fn)
+		}
+		return l
+	}
+	return v
+}
+
+// SnakeCaseKeys returns a copy of the node where all map keys are converted
+// to snake_case, like "userName" to "user_name"
+func (j *Node) SnakeCaseKeys() *Node {
+	return j.RemapKeys(snakeCase)
+}
+
+// CamelCaseKeys returns a copy of the node where all map keys are converted
+// to camelCase, like "user_name" to "userName"
+func (j *Node) CamelCaseKeys() *Node {
+	return j.RemapKeys(camelCase)
+}
+
+// LowerKeys returns a copy of the node where all map keys, recursively, are
+// converted to lowercase. If several keys in a map are the same when lowercased,
+// like "Foo" and "foo", an error is returned, unless lastWins is true, in which
+// case the value of the last key, in sorted order, is used.
+func (j *Node) LowerKeys(lastWins bool) (*Node, error) {
+	lowered, err := lowerKeys(j.data, lastWins)
+	if err != nil {
+		return NilNode, err
+	}
+	return &Node{lowered}, nil
+}
+
+// lowerKeys returns a copy of the given value, with all map keys converted to lowercase
+func lowerKeys(v interface{}, lastWins bool) (interface{}, error) {
+	switch v := v.(type) {
+	case *Node:
+		return lowerKeys(v.data, lastWins)
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for _, key := range (&Node{v}).Keys() {
+			lowerKey := strings.ToLower(key)
+			if _, exists := m[lowerKey]; exists && !lastWins {
+				return nil, errors.New("Duplicate key when lowercased: " + key)
+			}
+			val, err := lowerKeys(v[key], lastWins)
+			if err != nil {
+				return nil, err
+			}
+			m[lowerKey] = val
+		}
+		return m, nil
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, val := range v {
+			lowered, err := lowerKeys(val, lastWins)
+			if err != nil {
+				return nil, err
+			}
+			l[i] = lowered
+		}
+		return l, nil
+	}
+	return v, nil
+}
+
+// snakeCase converts a camelCase string to snake_case.
+// Acronyms are kept together, so "HTTPServer" becomes "http_server".
+func snakeCase(s string) string {
+	runes := []rune(s)
+	var sb strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && runes[i-1] != '_' && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				sb.WriteRune('_')
+			}
+			sb.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// camelCase converts a snake_case string to camelCase
+func camelCase(s string) string {
+	var sb strings.Builder
+	upper := false
+	for i, r := range s {
+		if r == '_' && i > 0 {
+			upper = true
+			continue
+		}
+		if upper {
+			sb.WriteRune(unicode.ToUpper(r))
+			upper = false
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// Pick returns a copy of a map, with only the given keys.
+// Keys that are not in the map are left out. Returns NilNode if the node is not a map.
+func (j *Node) Pick(keys ...string) *Node {
+	m, ok := j.CheckMap()
+	if !ok {
+		return NilNode
+	}
+	picked := make(map[string]interface{}, len(keys))
+	for _, key := range keys {
+		if val, ok := m[key]; ok {
+			picked[key] = deepCopy(val)
+		}
+	}
+	return &Node{picked}
+}
+
+// Omit returns a copy of a map, without the given keys.
+// Returns NilNode if the node is not a map.
+func (j *Node) Omit(keys ...string) *Node {
+	m, ok := j.CheckMap()
+	if !ok {
+		return NilNode
+	}
+	omitted := deepCopy(m).(map[string]interface{})
+	for _, key := range keys {
+		delete(omitted, key)
+	}
+	return &Node{omitted}
+}
+
+// PickPaths returns a new document with only the values at the given JSON paths,
+// with the maps and lists that lead to them recreated. Lists are padded with
+// nulls when picking an element that is not the first one.
+// JSON paths that are invalid or that do not lead to a value are skipped.
+func (j *Node) PickPaths(JSONpaths ...string) *Node {
+	var picked interface{}
+	for _, JSONpath := range JSONpaths {
+		cp, err := compileSinglePath(JSONpath)
+		if err != nil {
+			continue
+		}
+		n, _ := j.getNodes(cp)
+		if n == NilNode {
+			continue
+		}
+		picked = insertPath(picked, j.resolveIndexes(cp.segments), deepCopy(n.data))
+	}
+	if picked == nil {
+		picked = make(map[string]interface{})
+	}
+	return &Node{picked}
+}
+
+// insertPath inserts val at the given path segments, creating maps and lists as needed,
+// and returns the resulting container
+func insertPath(container interface{}, segments []pathSegment, val interface{}) interface{} {
+	if len(segments) == 0 {
+		return val
+	}
+	seg, rest := segments[0], segments[1:]
+	if seg.kind == indexSegment {
+		l, _ := container.([]interface{})
+		for len(l) <= seg.index {
+			l = append(l, nil)
+		}
+		l[seg.index] = insertPath(l[seg.index], rest, val)
+		return l
+	}
+	m, ok := container.(map[string]interface{})
+	if !ok {
+		m = make(map[string]interface{})
+	}
+	m[seg.key] = insertPath(m[seg.key], rest, val)
+	return m
+}
+
+// OmitPaths returns a copy of the document, without the values at the given JSON paths.
+// The JSON paths are removed in order, so removing a list element changes the
+// indexes of the following elements for the JSON paths that come after it.
+// JSON paths that are invalid or that do not lead to a value are skipped.
+func (j *Node) OmitPaths(JSONpaths ...string) *Node {
+	omitted := &Node{deepCopy(j.data)}
+	for _, JSONpath := range JSONpaths {
+		cp, err := compileSinglePath(JSONpath)
+		if err != nil || len(cp.segments) == 0 {
+			continue
+		}
+		cp = &CompiledPath{segments: omitted.resolveIndexes(cp.segments)}
+		_, parent := omitted.getNodes(cp)
+		last := cp.segments[len(cp.segments)-1]
+		if last.kind == indexSegment {
+			l, ok := parent.CheckList()
+			if !ok || last.index < 0 || last.index >= len(l) {
+				continue
+			}
+			l = append(l[:last.index:last.index], l[last.index+1:]...)
+			// The list is shorter now, so it must be replaced in its parent
+			if len(cp.segments) == 1 {
+				omitted.data = l
+			} else {
+				listPath := &CompiledPath{segments: cp.segments[:len(cp.segments)-1]}
+				_, grandparent := omitted.getNodes(listPath)
+				grandparent.setSegment(listPath.segments[len(listPath.segments)-1], l)
+			}
+			continue
+		}
+		if m, ok := parent.CheckMap(); ok {
+			delete(m, last.key)
+		}
+	}
+	return omitted
+}
+
+// Prune returns a copy of the document, without the values at the given JSON paths,
+// like OmitPaths. Unlike OmitPaths, all JSON paths refer to the original document,
+// the JSON paths may contain wildcards and slices, like ".users[*].password",
+// and the document is copied in a single pass, where pruned values are never copied.
+// JSON paths that are invalid, or that refer to the root node, are skipped.
+func (j *Node) Prune(JSONpaths ...string) *Node {
+	var paths [][]pathSegment
+	for _, JSONpath := range JSONpaths {
+		cp, err := compilePathCached(JSONpath)
+		if err != nil || len(cp.segments) == 0 {
+			continue
+		}
+		paths = append(paths, cp.segments)
+	}
+	return &Node{prune(j.data, paths)}
+}
+
+// prune returns a copy of v, without the values that the given paths lead to
+func prune(v interface{}, paths [][]pathSegment) interface{} {
+	if len(paths) == 0 {
+		return deepCopy(v)
+	}
+	// pruneChild returns the paths that continue below the given child,
+	// and false if the child itself should be pruned
+	pruneChild := func(key string, index, length int) ([][]pathSegment, bool) {
+		var rest [][]pathSegment
+		for _, path := range paths {
+			if !path[0].matches(key, index, length) {
+				continue
+			}
+			if len(path) == 1 {
+				return nil, false
+			}
+			rest = append(rest, path[1:])
+		}
+		return rest, true
+	}
+	switch v := v.(type) {
+	case *Node:
+		return prune(v.data, paths)
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			if rest, keep := pruneChild(key, -1, len(v)); keep {
+				m[key] = prune(val, rest)
+			}
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, 0, len(v))
+		for i, val := range v {
+			if rest, keep := pruneChild("", i, len(v)); keep {
+				l = append(l, prune(val, rest))
+			}
+		}
+		return l
+	}
+	return v
+}
+
+// Merge merges the other node into this node, recursively. Maps are merged
+// key by key, while for lists, strings, numbers, booleans and nulls,
+// the values from the other node replace the values in this node.
+// NilNode is never modified.
+func (j *Node) Merge(other *Node) {
+	if j == NilNode {
+		return
+	}
+	j.data = merge(j.data, other.data)
+}
+
+// merge returns the result of merging b into a. Maps in a are modified in place.
+func merge(a, b interface{}) interface{} {
+	if n, ok := a.(*Node); ok {
+		a = n.data
+	}
+	if n, ok := b.(*Node); ok {
+		b = n.data
+	}
+	am, ok := a.(map[string]interface{})
+	if !ok {
+		return deepCopy(b)
+	}
+	bm, ok := b.(map[string]interface{})
+	if !ok {
+		return deepCopy(b)
+	}
+	for key, val := range bm {
+		if existing, ok := am[key]; ok {
+			am[key] = merge(existing, val)
+		} else {
+			am[key] = deepCopy(val)
+		}
+	}
+	return am
+}
+
+// ApplyDefaults merges the defaults into this node, recursively, but only
+// sets keys that are missing. Values that are present, even nulls, are kept.
+// NilNode is never modified.
+func (j *Node) ApplyDefaults(defaults *Node) {
+	if j == NilNode {
+		return
+	}
+	j.data = applyDefaults(j.data, defaults.data)
+}
+
+// applyDefaults returns the result of adding the keys from defaults that are missing in v.
+// Maps in v are modified in place.
+func applyDefaults(v, defaults interface{}) interface{} {
+	if n, ok := v.(*Node); ok {
+		v = n.data
+	}
+	if n, ok := defaults.(*Node); ok {
+		defaults = n.data
+	}
+	vm, ok := v.(map[string]interface{})
+	if !ok {
+		return v
+	}
+	dm, ok := defaults.(map[string]interface{})
+	if !ok {
+		return v
+	}
+	for key, val := range dm {
+		if existing, ok := vm[key]; ok {
+			vm[key] = applyDefaults(existing, val)
+		} else {
+			vm[key] = deepCopy(val)
+		}
+	}
+	return vm
+}
+
+// ResolveEnv returns a new node where the "default" section of the document is
+// merged with the section for the given environment, like "production".
+// If there is no section for the given environment, the defaults are returned.
+// Returns an error if the node, or one of the two sections, is not a map.
+func (j *Node) ResolveEnv(envKey string) (*Node, error) {
+	if _, ok := j.CheckMap(); !ok {
+		return nil, errors.New("Can only resolve environments in a map. Not a map: " + j.Info())
+	}
+	result := &Node{make(map[string]interface{})}
+	for _, key := range []string{"default", envKey} {
+		section, ok := j.GetKey(key)
+		if !ok {
+			continue
+		}
+		if _, ok := section.CheckMap(); !ok {
+			return nil, errors.New("The " + key + " section is not a map: " + section.Info())
+		}
+		result.Merge(section)
+	}
+	return result, nil
+}
+
+// ExpandVars replaces ${NAME} in all strings with the value that lookup returns
+// for NAME. $${NAME} is replaced with a literal ${NAME}. If strict is true,
+// an error is returned for variables that lookup can not find, if not,
+// they are left as they are.
+func (j *Node) ExpandVars(lookup func(string) (string, bool), strict bool) error {
+	var err error
+	j.data = expandVars(j.data, lookup, strict, &err)
+	return err
+}
+
+// ExpandEnv replaces ${NAME} in all strings with the value of the environment variable NAME,
+// in the same way as ExpandVars
+func (j *Node) ExpandEnv(strict bool) error {
+	return j.ExpandVars(os.LookupEnv, strict)
+}
+
+// expandVars returns the given value with variables expanded in all strings.
+// err is set for the first variable that can not be found, if strict is true.
+func expandVars(v interface{}, lookup func(string) (string, bool), strict bool, err *error) interface{} {
+	switch v := v.(type) {
+	case string:
+		return expandString(v, lookup, strict, err)
+	case *Node:
+		v.data = expandVars(v.data, lookup, strict, err)
+	case map[string]interface{}:
+		for key, val := range v {
+			v[key] = expandVars(val, lookup, strict, err)
+		}
+	case []interface{}:
+		for i, val := range v {
+			v[i] = expandVars(val, lookup, strict, err)
+		}
+	}
+	return v
+}
+
+// expandString replaces ${NAME} in the given string with the value that lookup returns for NAME
+func expandString(s string, lookup func(string) (string, bool), strict bool, err *error) string {
+	if !strings.Contains(s, "${") {
+		return s
+	}
+	var sb strings.Builder
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			break
+		}
+		end := strings.Index(s[i:], "}")
+		if end < 0 {
+			break
+		}
+		end += i
+		if i > 0 && s[i-1] == '$' {
+			// An escaped variable, like $${NAME}
+			sb.WriteString(s[:i-1])
+			sb.WriteString(s[i : end+1])
+			s = s[end+1:]
+			continue
+		}
+		sb.WriteString(s[:i])
+		name := s[i+2 : end]
+		if value, ok := lookup(name); ok {
+			sb.WriteString(value)
+		} else {
+			if strict && *err == nil {
+				*err = errors.New("Variable not found: " + name)
+			}
+			sb.WriteString(s[i : end+1])
+		}
+		s = s[end+1:]
+	}
+	sb.WriteString(s)
+	return sb.String()
+}
+
+// ResolveRefs replaces all maps that only contain a "$ref" key with a JSON Pointer,
+// like {"$ref": "#/defaults/timeout"}, with a copy of the value that the JSON Pointer
+// refers to, within the same document. Returns an error for circular references,
+// and for references that can not be found, and then the document is not modified.
+func (j *Node) ResolveRefs() error {
+	data, err := j.resolveRefs(j.data, nil)
+	if err != nil {
+		return err
+	}
+	j.data = data
+	return nil
+}
+
+// resolveRefs returns a copy of the given value, with the references resolved. stack contains
+// the references that are currently being resolved, for detecting circular references.
+func (j *Node) resolveRefs(v interface{}, stack []string) (interface{}, error) {
+	switch v := v.(type) {
+	case *Node:
+		return j.resolveRefs(v.data, stack)
+	case map[string]interface{}:
+		if ref, ok := v["$ref"].(string); ok && len(v) == 1 {
+			for _, seen := range stack {
+				if seen == ref {
+					return nil, errors.New("Circular reference: " + ref)
+				}
+			}
+			target, ok := lookupPointer(j.data, ref)
+			if !ok {
+				return nil, errors.New("Reference not found: " + ref)
+			}
+			return j.resolveRefs(target, append(stack, ref))
+		}
+		m := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			resolved, err := j.resolveRefs(val, stack)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = resolved
+		}
+		return m, nil
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, val := range v {
+			resolved, err := j.resolveRefs(val, stack)
+			if err != nil {
+				return nil, err
+			}
+			l[i] = resolved
+		}
+		return l, nil
+	}
+	return v, nil
+}
+
+// lookupPointer returns the value that a JSON Pointer within the same document,
+// like "#/defaults/timeout", refers to
+func lookupPointer(root interface{}, pointer string) (interface{}, bool) {
+	if !strings.HasPrefix(pointer, "#") {
+		return nil, false
+	}
+	pointer = strings.TrimPrefix(pointer, "#")
+	if pointer == "" {
+		return root, true
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, false
+	}
+	current := root
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		if n, ok := current.(*Node); ok {
+			current = n.data
+		}
+		switch c := current.(type) {
+		case map[string]interface{}:
+			val, ok := c[token]
+			if !ok {
+				return nil, false
+			}
+			current = val
+		case []interface{}:
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || index >= len(c) {
+				return nil, false
+			}
+			current = c[index]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// TruncateStrings shortens all strings that are longer than max runes to
+// their first max runes followed by "…", which is useful for logging.
+// A negative max is treated as 0, so that all strings that are not empty become "…".
+// If inPlace is false, a copy of the node is truncated instead of the node.
+// Returns the truncated node and the number of strings that were shortened.
+func (j *Node) TruncateStrings(max int, inPlace bool) (*Node, int) {
+	if max < 0 {
+		max = 0
+	}
+	n := j
+	if !inPlace {
+		n = &Node{deepCopy(j.data)}
+	}
+	var count int
+	n.data = truncateStrings(n.data, max, &count)
+	return n, count
+}
+
+// truncateStrings returns the given value with all strings longer than max runes shortened,
+// and increases count for each string that is shortened
+func truncateStrings(v interface{}, max int, count *int) interface{} {
+	switch v := v.(type) {
+	case string:
+		if utf8.RuneCountInString(v) > max {
+			*count++
+			return string([]rune(v)[:max]) + "…"
+		}
+	case *Node:
+		v.data = truncateStrings(v.data, max, count)
+	case map[string]interface{}:
+		for key, val := range v {
+			v[key] = truncateStrings(val, max, count)
+		}
+	case []interface{}:
+		for i, val := range v {
+			v[i] = truncateStrings(val, max, count)
+		}
+	}
+	return v
+}
+
+// Entries converts a map to a list of maps with "key" and "value", sorted by key,
+// like {"a": 1} to [{"key": "a", "value": 1}]. Returns NilNode if the node is not a map.
+func (j *Node) Entries() *Node {
+	m, ok := j.CheckMap()
+	if !ok {
+		return NilNode
+	}
+	entries := make([]interface{}, 0, len(m))
+	for _, key := range j.Keys() {
+		entries = append(entries, map[string]interface{}{"key": key, "value": deepCopy(m[key])})
+	}
+	return &Node{entries}
+}
+
+// FromEntries converts a list of maps with "key" and "value" to a map, which is the
+// opposite of Entries. Returns NilNode if the node is not a list of maps with string keys.
+func (j *Node) FromEntries() *Node {
+	l, ok := j.CheckList()
+	if !ok {
+		return NilNode
+	}
+	m := make(map[string]interface{}, len(l))
+	for _, elem := range l {
+		entry := &Node{elem}
+		key, ok := entry.Get("key").CheckString()
+		if !ok {
+			return NilNode
+		}
+		m[key] = deepCopy(entry.Get("value").data)
+	}
+	return &Node{m}
+}
+
+// Invert swaps the keys and values of a flat map, like {"a": "x"} to {"x": "a"}.
+// Numbers, booleans and nulls are converted to strings before being used as keys,
+// while nested maps and lists give an error. If several keys have the same value,
+// an error is returned, unless lastWins is true, in which case the last key,
+// in sorted order, is used.
+func (j *Node) Invert(lastWins bool) (*Node, error) {
+	m, ok := j.CheckMap()
+	if !ok {
+		return NilNode, errors.New("Can only invert a map. Not a map: " + j.Info())
+	}
+	inverted := make(map[string]interface{}, len(m))
+	for _, key := range j.Keys() {
+		val := m[key]
+		if !isScalar(val) {
+			return NilNode, errors.New("Can not invert a nested value: " + key)
+		}
+		newKey := scalarString(val)
+		if _, exists := inverted[newKey]; exists && !lastWins {
+			return NilNode, errors.New("Duplicate value: " + newKey)
+		}
+		inverted[newKey] = key
+	}
+	return &Node{inverted}, nil
+}
+
+// FromFlat builds a nested document from a map where the keys are dotted paths,
+// like "servers.0.host", as often found in key/value stores. A backslash escapes
+// the following character, so that "a\.b" refers to the key "a.b".
+// Maps where all keys are the integers from 0 and up are converted to lists,
+// but only the maps that are built from the dotted paths, not the values in m.
+// If a key is both a value and a parent, like "a" and "a.b", the parent wins.
+func FromFlat(m map[string]interface{}) *Node {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	// Sort the keys, so that parents like "a.b" are set after values like "a"
+	sort.Strings(keys)
+	root := make(flatTree)
+	for _, key := range keys {
+		parts := splitPath(key)
+		curr := root
+		for _, part := range parts[:len(parts)-1] {
+			name := unescapePath(part)
+			next, ok := curr[name].(flatTree)
+			if !ok {
+				next = make(flatTree)
+				curr[name] = next
+			}
+			curr = next
+		}
+		name := unescapePath(parts[len(parts)-1])
+		if _, ok := curr[name].(flatTree); !ok {
+			curr[name] = deepCopy(m[key])
+		}
+	}
+	return &Node{mapsToLists(root)}
+}
+
+// flatTree is a map that is built by FromFlat, as opposed to a map value from the flat map
+type flatTree map[string]interface{}
+
+// mapsToLists converts the flatTree maps in v to lists if all keys are the integers
+// from 0 and up, or to regular maps if not, recursively
+func mapsToLists(v interface{}) interface{} {
+	t, ok := v.(flatTree)
+	if !ok {
+		return v
+	}
+	m := map[string]interface{}(t)
+	for key, val := range m {
+		m[key] = mapsToLists(val)
+	}
+	if len(m) == 0 {
+		return m
+	}
+	l := make([]interface{}, len(m))
+	for key, val := range m {
+		i, err := strconv.Atoi(key)
+		if err != nil || i < 0 || i >= len(m) || strconv.Itoa(i) != key {
+			return m
+		}
+		l[i] = val
+	}
+	return l
+}
+
+// Numify converts all json.Number values in the document, as decoded by NewNumber,
+// to int64 if they are integers that fit in an int64, or to float64 if not.
+// Useful for passing the data on to code that expects int64 and float64 values.
+func (j *Node) Numify() {
+	j.data = numify(j.data)
+}
+
+// numify converts the json.Number values in v to int64 or float64, modifying
+// maps and lists in place, and returns the resulting value
+func numify(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, val := range v {
+			v[key] = numify(val)
+		}
+	case []interface{}:
+		for i, val := range v {
+			v[i] = numify(val)
+		}
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+	}
+	return v
+}
@@ -0,0 +1,366 @@
+package jpath
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/bmizerany/assert"
+)
+
+func TestRemapKeysCollision(t *testing.T) {
+	js, err := New([]byte(`{"user_name": 1, "userName": 2, "nested": {"a_b": 3, "aB": 4}}`))
+	assert.Equal(t, nil, err)
+
+	// The last key in sorted order wins, every time
+	for i := 0; i < 20; i++ {
+		remapped := js.CamelCaseKeys()
+		assert.Equal(t, 1, remapped.Get("userName").Int())
+		assert.Equal(t, 3, remapped.GetNode(".nested.aB").Int())
+	}
+}
+
+func TestCaseKeys(t *testing.T) {
+	assert.Equal(t, "user_name", snakeCase("userName"))
+	assert.Equal(t, "http_server", snakeCase("HTTPServer"))
+	assert.Equal(t, "user_id", snakeCase("userID"))
+	assert.Equal(t, "already_snake", snakeCase("already_snake"))
+	assert.Equal(t, "userName", camelCase("user_name"))
+	assert.Equal(t, "_private", camelCase("_private"))
+
+	js, err := New([]byte(`{"userName": "bob", "homeAddress": {"streetName": "Main", "zipCode": 1234}, "tagList": [{"tagName": "a"}]}`))
+	assert.Equal(t, nil, err)
+
+	snake := js.SnakeCaseKeys()
+	assert.Equal(t, "bob", snake.Get("user_name").String())
+	assert.Equal(t, "Main", snake.GetNode(".home_address.street_name").String())
+	assert.Equal(t, 1234, snake.GetNode(".home_address.zip_code").Int())
+	assert.Equal(t, "a", snake.GetNode(".tag_list[0].tag_name").String())
+
+	// The original is not modified
+	assert.Equal(t, "bob", js.Get("userName").String())
+
+	camel := snake.CamelCaseKeys()
+	assert.Equal(t, js, camel)
+}
+
+func TestPickOmit(t *testing.T) {
+	js, err := New([]byte(`{"a": 1, "b": {"c": 2}, "d": 3}`))
+	assert.Equal(t, nil, err)
+
+	picked := js.Pick("a", "b", "missing")
+	assert.Equal(t, []string{"a", "b"}, picked.Keys())
+	assert.Equal(t, 2, picked.GetNode(".b.c").Int())
+
+	// The picked values are copies
+	picked.Get("b").Set("c", 42)
+	assert.Equal(t, 2, js.GetNode(".b.c").Int())
+
+	omitted := js.Omit("b")
+	assert.Equal(t, []string{"a", "d"}, omitted.Keys())
+	assert.Equal(t, []string{"a", "b", "d"}, js.Keys())
+
+	assert.Equal(t, NilNode, js.Get("a").Pick("a"))
+	assert.Equal(t, NilNode, js.Get("a").Omit("a"))
+}
+
+func TestPickOmitPaths(t *testing.T) {
+	js, err := New([]byte(`{"a": {"b": "secret", "e": "public"}, "c": [1, 2, 3], "d": 4}`))
+	assert.Equal(t, nil, err)
+
+	picked := js.PickPaths(".a.b", ".c", ".missing", ".a[")
+	data, err := picked.JSON()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `{"a":{"b":"secret"},"c":[1,2,3]}`, string(data))
+
+	picked = js.PickPaths(".c[1]")
+	data, err = picked.JSON()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `{"c":[null,2]}`, string(data))
+
+	omitted := js.OmitPaths(".a.b", ".c[0]")
+	data, err = omitted.JSON()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `{"a":{"e":"public"},"c":[2,3],"d":4}`, string(data))
+
+	// The original is not modified
+	assert.Equal(t, "secret", js.GetNode(".a.b").String())
+	assert.Equal(t, 3, len(js.Get("c").List()))
+}
+
+func TestMerge(t *testing.T) {
+	js, err := New([]byte(`{"a": {"b": 1, "c": [1, 2]}, "d": "e"}`))
+	assert.Equal(t, nil, err)
+	other, err := New([]byte(`{"a": {"b": 2, "c": [3], "f": true}, "g": null}`))
+	assert.Equal(t, nil, err)
+
+	js.Merge(other)
+	data, err := js.JSON()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `{"a":{"b":2,"c":[3],"f":true},"d":"e","g":null}`, string(data))
+
+	// The merged values are copies
+	other.GetNode(".a.c").List()[0] = 42.0
+	assert.Equal(t, 3, js.GetNode(".a.c").Get(0).Int())
+}
+
+func TestExpandVars(t *testing.T) {
+	lookup := func(name string) (string, bool) {
+		if name == "HOME" {
+			return "/home/bob", true
+		}
+		return "", false
+	}
+
+	js, err := New([]byte(`{"data": "${HOME}/data", "list": ["${MISSING}", "$${HOME}", "${HOME}${HOME}"]}`))
+	assert.Equal(t, nil, err)
+	err = js.ExpandVars(lookup, false)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "/home/bob/data", js.Get("data").String())
+	assert.Equal(t, "${MISSING}", js.Get("list", 0).String())
+	assert.Equal(t, "${HOME}", js.Get("list", 1).String())
+	assert.Equal(t, "/home/bob/home/bob", js.Get("list", 2).String())
+
+	js, err = New([]byte(`{"data": "${HOME}/data", "missing": "${MISSING}"}`))
+	assert.Equal(t, nil, err)
+	err = js.ExpandVars(lookup, true)
+	assert.NotEqual(t, nil, err)
+	assert.Equal(t, "/home/bob/data", js.Get("data").String())
+
+	os.Setenv("JPATH_TEST_VAR", "value")
+	defer os.Unsetenv("JPATH_TEST_VAR")
+	js, err = New([]byte(`["${JPATH_TEST_VAR}"]`))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, js.ExpandEnv(true))
+	assert.Equal(t, "value", js.Get(0).String())
+}
+
+func TestResolveRefs(t *testing.T) {
+	js, err := New([]byte(`{
+		"defaults": {"timeout": 30, "retry": {"$ref": "#/retries/1"}},
+		"retries": [1, {"count": 3}],
+		"server": {"timeout": {"$ref": "#/defaults/timeout"}},
+		"client": {"$ref": "#/defaults"}
+	}`))
+	assert.Equal(t, nil, err)
+
+	err = js.ResolveRefs()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 30, js.GetNode(".server.timeout").Int())
+	assert.Equal(t, 3, js.GetNode(".client.retry.count").Int())
+	assert.Equal(t, 3, js.GetNode(".defaults.retry.count").Int())
+
+	js, err = New([]byte(`{"a": {"$ref": "#/b"}, "b": {"c": {"$ref": "#/a"}}}`))
+	assert.Equal(t, nil, err)
+	assert.NotEqual(t, nil, js.ResolveRefs())
+
+	js, err = New([]byte(`{"a": {"$ref": "#/missing"}}`))
+	assert.Equal(t, nil, err)
+	assert.NotEqual(t, nil, js.ResolveRefs())
+
+	// The document is not modified if a reference can not be resolved
+	js, err = New([]byte(`{"a": {"$ref": "#/x"}, "x": 1, "z": {"$ref": "#/missing"}}`))
+	assert.Equal(t, nil, err)
+	assert.NotEqual(t, nil, js.ResolveRefs())
+	assert.Equal(t, "#/x", js.GetNode(".a.$ref").String())
+
+	// The resolved values are copies
+	js, err = New([]byte(`{"a": {"$ref": "#/b"}, "b": {"c": 1}}`))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, js.ResolveRefs())
+	js.Get("a").Set("c", 2)
+	assert.Equal(t, 1, js.GetNode(".b.c").Int())
+}
+
+func TestTruncateStrings(t *testing.T) {
+	js, err := New([]byte(`{"long": "abcdefghij", "short": "abc", "list": ["æøåæøå", 42]}`))
+	assert.Equal(t, nil, err)
+
+	truncated, count := js.TruncateStrings(5, false)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, "abcde…", truncated.Get("long").String())
+	assert.Equal(t, "abc", truncated.Get("short").String())
+	assert.Equal(t, "æøåæø…", truncated.Get("list", 0).String())
+	assert.Equal(t, "abcdefghij", js.Get("long").String())
+
+	truncated, count = js.TruncateStrings(5, true)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, js, truncated)
+	assert.Equal(t, "abcde…", js.Get("long").String())
+
+	truncated, count = js.TruncateStrings(-1, false)
+	assert.Equal(t, 3, count)
+	assert.Equal(t, "…", truncated.Get("short").String())
+}
+
+func TestEntries(t *testing.T) {
+	js, err := New([]byte(`{"b": 2, "a": {"c": [1]}}`))
+	assert.Equal(t, nil, err)
+
+	entries := js.Entries()
+	data, err := entries.JSON()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `[{"key":"a","value":{"c":[1]}},{"key":"b","value":2}]`, string(data))
+
+	assert.Equal(t, js, entries.FromEntries())
+
+	assert.Equal(t, NilNode, js.Get("b").Entries())
+	assert.Equal(t, NilNode, js.GetNode(".a.c").FromEntries())
+}
+
+func TestInvert(t *testing.T) {
+	js, err := New([]byte(`{"a": "x", "b": "y", "c": 3}`))
+	assert.Equal(t, nil, err)
+
+	inverted, err := js.Invert(false)
+	assert.Equal(t, nil, err)
+	data, err := inverted.JSON()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `{"3":"c","x":"a","y":"b"}`, string(data))
+
+	js.Set("d", "x")
+	_, err = js.Invert(false)
+	assert.NotEqual(t, nil, err)
+	inverted, err = js.Invert(true)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "d", inverted.Get("x").String())
+
+	js.Set("e", []interface{}{})
+	_, err = js.Invert(true)
+	assert.NotEqual(t, nil, err)
+}
+
+func TestResolveEnv(t *testing.T) {
+	js, err := New([]byte(`{
+		"default": {"db": {"host": "localhost", "port": 5432}, "debug": true},
+		"production": {"db": {"host": "db.example.com"}, "replicas": 3}
+	}`))
+	assert.Equal(t, nil, err)
+
+	prod, err := js.ResolveEnv("production")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "db.example.com", prod.GetNode(".db.host").String())
+	assert.Equal(t, 5432, prod.GetNode(".db.port").Int())
+	assert.Equal(t, true, prod.Get("debug").Bool())
+	assert.Equal(t, 3, prod.Get("replicas").Int())
+
+	// The original document is not modified
+	assert.Equal(t, "localhost", js.GetNode(".default.db.host").String())
+
+	staging, err := js.ResolveEnv("staging")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "localhost", staging.GetNode(".db.host").String())
+	assert.Equal(t, NilNode, staging.Get("replicas"))
+
+	_, err = js.Get("default").Get("db").Get("host").ResolveEnv("production")
+	assert.NotEqual(t, nil, err)
+}
+
+func TestApplyDefaults(t *testing.T) {
+	js, err := New([]byte(`{"db": {"host": "db.example.com", "password": null}, "debug": false}`))
+	assert.Equal(t, nil, err)
+	defaults, err := New([]byte(`{"db": {"host": "localhost", "port": 5432, "password": "secret"}, "debug": true, "log": {"level": "info"}}`))
+	assert.Equal(t, nil, err)
+
+	js.ApplyDefaults(defaults)
+	assert.Equal(t, "db.example.com", js.GetNode(".db.host").String())
+	assert.Equal(t, 5432, js.GetNode(".db.port").Int())
+	assert.Equal(t, nil, js.GetNode(".db.password").Interface())
+	assert.Equal(t, false, js.Get("debug").Bool(true))
+	assert.Equal(t, "info", js.GetNode(".log.level").String())
+
+	// The defaults are copied, not shared
+	js.GetNode(".log").Set("level", "debug")
+	assert.Equal(t, "info", defaults.GetNode(".log.level").String())
+}
+
+func TestFromFlat(t *testing.T) {
+	js := FromFlat(map[string]interface{}{
+		"name":             "web",
+		"servers.0.host":   "a.example.com",
+		"servers.0.port":   80,
+		"servers.1.host":   "b.example.com",
+		"ids.0":            "first",
+		"ids.2":            "third",
+		`labels.app\.kind`: "frontend",
+	})
+	assert.Equal(t, "web", js.Get("name").String())
+	assert.Equal(t, "a.example.com", js.GetNode(".servers[0].host").String())
+	assert.Equal(t, 80, js.GetNode(".servers[0].port").Int())
+	assert.Equal(t, "b.example.com", js.GetNode(".servers[1].host").String())
+	assert.Equal(t, 2, len(js.Get("servers").List()))
+
+	// Not contiguous, so this is a map
+	assert.Equal(t, "third", js.GetNode(".ids.2").String())
+	assert.Equal(t, "frontend", js.Get("labels").Get("app.kind").String())
+
+	// Map values are kept as they are, even if the keys are integers
+	js = FromFlat(map[string]interface{}{
+		"columns": map[string]interface{}{"0": "id", "1": "name"},
+		"a":       "value",
+		"a.b":     "parent",
+	})
+	assert.Equal(t, "name", js.Get("columns").Get("1").String())
+	_, ok := js.Get("columns").CheckMap()
+	assert.Equal(t, true, ok)
+	assert.Equal(t, "parent", js.GetNode(".a.b").String())
+}
+
+func TestPrune(t *testing.T) {
+	js, err := New([]byte(`{
+		"users": [{"name": "a", "password": "x"}, {"name": "b", "password": "y"}],
+		"billing": {"card": "1234", "plan": "pro"},
+		"items": [1, 2, 3]
+	}`))
+	assert.Equal(t, nil, err)
+
+	pruned := js.Prune(".billing.card", ".users[*].password", ".items[0]", ".items[1]", ".missing.key", ".bad[x]")
+	data, err := pruned.JSON()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `{"billing":{"plan":"pro"},"items":[3],"users":[{"name":"a"},{"name":"b"}]}`, string(data))
+
+	// The original is not modified
+	assert.Equal(t, "1234", js.GetNode(".billing.card").String())
+	assert.Equal(t, "x", js.GetNode(".users[0].password").String())
+	assert.Equal(t, 3, len(js.Get("items").List()))
+
+	// The copy does not share data with the original
+	pruned.GetNode(".billing").Set("plan", "free")
+	assert.Equal(t, "pro", js.GetNode(".billing.plan").String())
+}
+
+func TestNumify(t *testing.T) {
+	js, err := NewNumber([]byte(`{"count": 42, "ratio": 0.25, "big": 9007199254740993, "huge": 1e400, "list": [1, 2.5]}`))
+	assert.Equal(t, nil, err)
+
+	js.Numify()
+	assert.Equal(t, int64(42), js.Get("count").Interface())
+	assert.Equal(t, 0.25, js.Get("ratio").Interface())
+	assert.Equal(t, int64(9007199254740993), js.Get("big").Interface())
+	assert.Equal(t, int64(1), js.GetNode(".list[0]").Interface())
+	assert.Equal(t, 2.5, js.GetNode(".list[1]").Interface())
+	// Numbers that can not be converted are kept as they are
+	assert.Equal(t, json.Number("1e400"), js.Get("huge").Interface())
+}
+
+func TestLowerKeys(t *testing.T) {
+	js, err := New([]byte(`{"UserName": "a", "Address": {"ZipCode": "1234", "Items": [{"ID": 1}]}}`))
+	assert.Equal(t, nil, err)
+
+	lowered, err := js.LowerKeys(false)
+	assert.Equal(t, nil, err)
+	data, err := lowered.JSON()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `{"address":{"items":[{"id":1}],"zipcode":"1234"},"username":"a"}`, string(data))
+	assert.Equal(t, "a", js.Get("UserName").String())
+
+	collision, err := New([]byte(`{"nested": {"Foo": 1, "foo": 2}}`))
+	assert.Equal(t, nil, err)
+	_, err = collision.LowerKeys(false)
+	assert.NotEqual(t, nil, err)
+
+	// "foo" comes after "Foo" in sorted order
+	lowered, err = collision.LowerKeys(true)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, lowered.GetNode(".nested.foo").Int())
+}
@@ -0,0 +1,92 @@
+package jpath
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+// Tree returns the node as an indented tree, like the output of the tree command,
+// with map keys, list indexes and values, annotated with the type of each value
+func (j *Node) Tree() string {
+	var buf bytes.Buffer
+	j.WriteTree(&buf, 0, true)
+	return buf.String()
+}
+
+// WriteTree writes the node as an indented tree to the given io.Writer.
+// If maxDepth is larger than 0, maps and lists deeper than maxDepth are shown
+// as {...} and [...]. If types is true, each value is annotated with its type.
+func (j *Node) WriteTree(w io.Writer, maxDepth int, types bool) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(treeLabel(".", j.data, 0, maxDepth, types) + "\n")
+	writeTree(bw, j.data, "", 1, maxDepth, types)
+	return bw.Flush()
+}
+
+// treeLabel returns the line for the given value in a tree, starting with the given name
+func treeLabel(name string, v interface{}, depth, maxDepth int, types bool) string {
+	if n, ok := v.(*Node); ok {
+		v = n.data
+	}
+	label := name
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			label += ": {}"
+		} else if maxDepth > 0 && depth >= maxDepth {
+			label += ": {...}"
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			label += ": []"
+		} else if maxDepth > 0 && depth >= maxDepth {
+			label += ": [...]"
+		}
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			data = []byte("?")
+		}
+		label += ": " + string(data)
+	}
+	if types {
+		label += " (" + (&Node{v}).Type().String() + ")"
+	}
+	return label
+}
+
+// writeTree writes the elements of a map or list as branches of a tree,
+// where prefix is the indentation that is written before each line
+func writeTree(w *bufio.Writer, v interface{}, prefix string, depth, maxDepth int, types bool) {
+	if maxDepth > 0 && depth > maxDepth {
+		return
+	}
+	var names []string
+	var values []interface{}
+	switch v := v.(type) {
+	case *Node:
+		writeTree(w, v.data, prefix, depth, maxDepth, types)
+		return
+	case map[string]interface{}:
+		for _, key := range (&Node{v}).Keys() {
+			names = append(names, key)
+			values = append(values, v[key])
+		}
+	case []interface{}:
+		for i, val := range v {
+			names = append(names, "["+strconv.Itoa(i)+"]")
+			values = append(values, val)
+		}
+	}
+	for i, name := range names {
+		branch, indent := "├── ", "│   "
+		if i == len(names)-1 {
+			branch, indent = "└── ", "    "
+		}
+		w.WriteString(prefix + branch + treeLabel(name, values[i], depth, maxDepth, types) + "\n")
+		writeTree(w, values[i], prefix+indent, depth+1, maxDepth, types)
+	}
+}
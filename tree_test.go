@@ -0,0 +1,37 @@
+package jpath
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/bmizerany/assert"
+)
+
+func TestTree(t *testing.T) {
+	js, err := New([]byte(`{"a": {"b": [1, {"c": "deep"}], "e": {}}, "d": true, "f": null}`))
+	assert.Equal(t, nil, err)
+
+	expected := `. (map)
+├── a (map)
+│   ├── b (list)
+│   │   ├── [0]: 1 (number)
+│   │   └── [1] (map)
+│   │       └── c: "deep" (string)
+│   └── e: {} (map)
+├── d: true (bool)
+└── f: null (null)
+`
+	assert.Equal(t, expected, js.Tree())
+
+	var buf bytes.Buffer
+	err = js.WriteTree(&buf, 2, false)
+	assert.Equal(t, nil, err)
+	expected = `.
+├── a
+│   ├── b: [...]
+│   └── e: {}
+├── d: true
+└── f: null
+`
+	assert.Equal(t, expected, buf.String())
+}
@@ -0,0 +1,75 @@
+package jpath
+
+// NodeType is the JSON type of the value in a node
+type NodeType int
+
+// The JSON types that a node can have. UnknownType is used for values that
+// are not JSON types, and when there is no type to return.
+const (
+	UnknownType NodeType = iota
+	NullType
+	BoolType
+	NumberType
+	StringType
+	ListType
+	MapType
+)
+
+// String returns the name of the JSON type, like "string" or "map"
+func (t NodeType) String() string {
+	switch t {
+	case NullType:
+		return "null"
+	case BoolType:
+		return "bool"
+	case NumberType:
+		return "number"
+	case StringType:
+		return "string"
+	case ListType:
+		return "list"
+	case MapType:
+		return "map"
+	}
+	return "unknown"
+}
+
+// Type returns the JSON type of the value in the node
+func (j *Node) Type() NodeType {
+	switch j.data.(type) {
+	case nil:
+		return NullType
+	case bool:
+		return BoolType
+	case string:
+		return StringType
+	case []interface{}:
+		return ListType
+	case map[string]interface{}:
+		return MapType
+	}
+	if isNumber(j.data) {
+		return NumberType
+	}
+	return UnknownType
+}
+
+// IsHomogeneous checks if all elements of a list have the same JSON type, and returns
+// the type. Useful for finding lists of numbers that contain a stray string, for instance.
+// An empty list gives UnknownType and true, while a node that is not a list gives false.
+func (j *Node) IsHomogeneous() (NodeType, bool) {
+	l, ok := j.CheckList()
+	if !ok {
+		return UnknownType, false
+	}
+	if len(l) == 0 {
+		return UnknownType, true
+	}
+	t := (&Node{l[0]}).Type()
+	for _, elem := range l[1:] {
+		if (&Node{elem}).Type() != t {
+			return UnknownType, false
+		}
+	}
+	return t, true
+}
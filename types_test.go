@@ -0,0 +1,37 @@
+package jpath
+
+import (
+	"testing"
+
+	"github.com/bmizerany/assert"
+)
+
+func TestType(t *testing.T) {
+	js, err := NewNumber([]byte(`{"a": null, "b": true, "c": 1, "d": "x", "e": [], "f": {}}`))
+	assert.Equal(t, nil, err)
+	expected := map[string]NodeType{"a": NullType, "b": BoolType, "c": NumberType, "d": StringType, "e": ListType, "f": MapType}
+	for key, nodeType := range expected {
+		assert.Equal(t, nodeType, js.Get(key).Type())
+	}
+	assert.Equal(t, MapType, js.Type())
+	assert.Equal(t, "number", js.Get("c").Type().String())
+}
+
+func TestIsHomogeneous(t *testing.T) {
+	numbers, err := New([]byte(`[1, 2.5, 3]`))
+	assert.Equal(t, nil, err)
+	nodeType, ok := numbers.IsHomogeneous()
+	assert.Equal(t, true, ok)
+	assert.Equal(t, NumberType, nodeType)
+
+	mixed, err := New([]byte(`[1, "2", 3]`))
+	assert.Equal(t, nil, err)
+	_, ok = mixed.IsHomogeneous()
+	assert.Equal(t, false, ok)
+
+	nodeType, ok = (&Node{[]interface{}{}}).IsHomogeneous()
+	assert.Equal(t, true, ok)
+	assert.Equal(t, UnknownType, nodeType)
+	_, ok = NewNode().IsHomogeneous()
+	assert.Equal(t, false, ok)
+}
@@ -2,16 +2,16 @@ package jpath
 
 import (
 	"bytes"
-	"strings"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
 )
 
 // lastpart returns the last part of a given JSON path
 func lastpart(JSONpath string) string {
-	if !strings.Contains(JSONpath, ".") {
-		return JSONpath
-	}
-	parts := strings.Split(JSONpath, ".")
-	return parts[len(parts)-1]
+	parts := splitPath(JSONpath)
+	return unescapePath(parts[len(parts)-1])
 }
 
 // badd can concatenate several byte slices
@@ -22,3 +22,107 @@ func badd(args ...[]byte) []byte {
 	}
 	return buf.Bytes()
 }
+
+// checkJSON returns an error if the given value, found at the given JSON path,
+// can not be encoded as JSON. Map keys are checked in sorted order, so that
+// the error is always about the same value.
+func checkJSON(JSONpath string, v interface{}) error {
+	switch v := v.(type) {
+	case nil, bool, string, json.Number,
+		int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64:
+		return nil
+	case float32:
+		return checkFloat(JSONpath, float64(v))
+	case float64:
+		return checkFloat(JSONpath, v)
+	case *Node:
+		if v == nil {
+			return nil
+		}
+		return checkJSON(JSONpath, v.data)
+	case map[string]interface{}:
+		for _, key := range (&Node{v}).Keys() {
+			if err := checkJSON(keyPath(JSONpath, key), v[key]); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []interface{}:
+		for i, val := range v {
+			if err := checkJSON(indexPath(JSONpath, i), val); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if _, err := json.Marshal(v); err != nil {
+		return fmt.Errorf("can not encode %T at %s as JSON: %v", v, JSONpath, err)
+	}
+	return nil
+}
+
+// checkFloat returns an error if the given float can not be encoded as JSON
+func checkFloat(JSONpath string, f float64) error {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return fmt.Errorf("can not encode %v at %s as JSON", f, JSONpath)
+	}
+	return nil
+}
+
+// scalarString returns a string representation of a string, number, bool or nil value
+func scalarString(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	}
+	return fmt.Sprint(v)
+}
+
+// isScalar checks if the given value is not a map or a list
+func isScalar(v interface{}) bool {
+	switch v := v.(type) {
+	case map[string]interface{}, []interface{}:
+		return false
+	case *Node:
+		return isScalar(v.data)
+	}
+	return true
+}
+
+// deepCopy returns a copy of the given value, where all maps and lists are copied recursively
+func deepCopy(v interface{}) interface{} {
+	switch v := v.(type) {
+	case *Node:
+		return deepCopy(v.data)
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			m[key] = deepCopy(val)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, val := range v {
+			l[i] = deepCopy(val)
+		}
+		return l
+	}
+	return v
+}
+
+// keyPath returns the JSON path to the given key in the map at the given JSON path
+func keyPath(JSONpath, key string) string {
+	return JSONpath + "." + escapePath(key)
+}
+
+// indexPath returns the JSON path to the given index in the list at the given JSON path
+func indexPath(JSONpath string, index int) string {
+	return JSONpath + "[" + strconv.Itoa(index) + "]"
+}